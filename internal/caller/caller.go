@@ -0,0 +1,110 @@
+// Package caller finds the application code issuing a query.
+package caller
+
+import (
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Function name prefixes of frames that are never reported as the caller
+var skippedPrefixes = []string{
+	"github.com/hashicorp/go-gin-gorm-opencensus/internal/",
+	"github.com/hashicorp/go-gin-gorm-opencensus/pkg/ocgorm",
+	"github.com/hashicorp/go-gin-gorm-opencensus/pkg/otelgorm",
+	"github.com/jinzhu/gorm",
+	"gorm.io/",
+	"database/sql.",
+	"reflect.",
+	"runtime.",
+}
+
+// Maximum depth of the stack searched for a caller
+const maxDepth = 64
+
+// Frame is the location of the calling code.
+type Frame struct {
+	Function string
+	File     string
+	Line     int
+}
+
+type cachedFrame struct {
+	frame Frame
+	ok    bool
+}
+
+// Resolved frames keyed by program counter.
+// The cache is bounded by the number of call sites in the binary.
+var cache sync.Map
+
+// Find returns the first frame of the stack outside of gorm and this project's instrumentation.
+func Find() (Frame, bool) {
+	pc, ok := FindPC()
+	if !ok {
+		return Frame{}, false
+	}
+
+	return resolve(pc)
+}
+
+// FindPC returns the program counter of the frame returned by Find (eg. the source of a log record).
+func FindPC() (uintptr, bool) {
+	var pcs [maxDepth]uintptr
+
+	n := runtime.Callers(2, pcs[:])
+
+	for _, pc := range pcs[:n] {
+		if _, ok := resolve(pc); ok {
+			return pc, true
+		}
+	}
+
+	return 0, false
+}
+
+func resolve(pc uintptr) (Frame, bool) {
+	if cached, ok := cache.Load(pc); ok {
+		c := cached.(cachedFrame)
+
+		return c.frame, c.ok
+	}
+
+	var c cachedFrame
+
+	// A single program counter expands to several frames when calls are inlined
+	frames := runtime.CallersFrames([]uintptr{pc})
+	for {
+		frame, more := frames.Next()
+		if !skipped(frame.Function) {
+			c = cachedFrame{
+				frame: Frame{Function: frame.Function, File: frame.File, Line: frame.Line},
+				ok:    true,
+			}
+
+			break
+		}
+
+		if !more {
+			break
+		}
+	}
+
+	cache.Store(pc, c)
+
+	return c.frame, c.ok
+}
+
+func skipped(function string) bool {
+	if function == "" {
+		return true
+	}
+
+	for _, prefix := range skippedPrefixes {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,71 @@
+package core
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+// Attributes recorded on the span for the queries, exported by ocgorm.
+const (
+	ResourceNameAttribute = "resource.name"
+	TableAttribute        = "gorm.table"
+	ModelAttribute        = "gorm.model"
+	ParametersAttribute   = "db.statement.parameters"
+	SQLQueryAttribute     = "sql.query"
+
+	FingerprintAttribute = "db.statement.fingerprint"
+
+	QueryCountAttribute = "db.query_count"
+	TotalTimeAttribute  = "db.total_time_ms"
+	RowsAttribute       = "db.rows"
+
+	CodeFunctionAttribute = "code.function"
+	CodeFilepathAttribute = "code.filepath"
+	CodeLinenoAttribute   = "code.lineno"
+)
+
+// Tags applied to the measures, exported by ocgorm.
+var (
+	Operation, _    = tag.NewKey("sql.operation")
+	Table, _        = tag.NewKey("sql.table")
+	Model, _        = tag.NewKey("sql.model")
+	DatabaseName, _ = tag.NewKey("database_name")
+
+	// LimitedTag is the key of the tag values dropped by the TagLimiter.
+	LimitedTag, _ = tag.NewKey("sql.limited_tag")
+)
+
+// Query measures, exported by ocgorm.
+var (
+	MeasureQueryCount          = stats.Int64("go.sql/client/calls", "Number of queries started", stats.UnitDimensionless)
+	MeasureLatencyMs           = stats.Float64("go.sql/client/latency", "The latency of calls in milliseconds", stats.UnitMilliseconds)
+	MeasureTruncatedAttributes = stats.Int64("go.sql/client/truncated_attributes", "Number of span attribute values truncated", stats.UnitDimensionless)
+	MeasureNPlusOne            = stats.Int64("go.sql/client/n_plus_one", "Number of repeated queries detected within a request", stats.UnitDimensionless)
+	MeasureDroppedTagValues    = stats.Int64("go.sql/client/dropped_tag_values", "Number of tag values replaced by the cardinality limiter", stats.UnitDimensionless)
+)
+
+// Connection pool measures, exported by ocgorm.
+var (
+	MeasureOpenConnections    = stats.Int64("go.sql/connections/open", "Count of open connections in the pool", stats.UnitDimensionless)
+	MeasureIdleConnections    = stats.Int64("go.sql/connections/idle", "Count of idle connections in the pool", stats.UnitDimensionless)
+	MeasureActiveConnections  = stats.Int64("go.sql/connections/active", "Count of active connections in the pool", stats.UnitDimensionless)
+	MeasureWaitCount          = stats.Int64("go.sql/connections/wait_count", "The total number of connections waited for", stats.UnitDimensionless)
+	MeasureWaitDuration       = stats.Float64("go.sql/connections/wait_duration", "The total time blocked waiting for a new connection", stats.UnitMilliseconds)
+	MeasureIdleClosed         = stats.Int64("go.sql/connections/idle_closed", "The total number of connections closed due to SetMaxIdleConns", stats.UnitDimensionless)
+	MeasureLifetimeClosed     = stats.Int64("go.sql/connections/lifetime_closed", "The total number of connections closed due to SetConnMaxLifetime", stats.UnitDimensionless)
+	MeasureMaxOpenConnections = stats.Int64("go.sql/connections/max_open", "Maximum number of open connections to the database", stats.UnitDimensionless)
+	MeasureIdleTimeClosed     = stats.Int64("go.sql/connections/idle_time_closed", "The total number of connections closed due to SetConnMaxIdleTime", stats.UnitDimensionless)
+	MeasurePoolSaturation     = stats.Float64("go.sql/connections/saturation", "Ratio of connections in use to the maximum number of open connections", stats.UnitDimensionless)
+	MeasureWaitRate           = stats.Float64("go.sql/connections/wait_rate", "Number of connections waited for per second over the last interval", "1/s")
+	MeasureAverageWait        = stats.Float64("go.sql/connections/average_wait", "Average time blocked waiting for a new connection over the last interval", stats.UnitMilliseconds)
+)
+
+// Connection pool counters recorded as the increase of the sql.DBStats totals since the
+// previous record, aggregated by view.Sum into cumulative metrics, exported by ocgorm.
+var (
+	MeasureWaits                     = stats.Int64("go.sql/connections/waits", "Number of connections waited for", stats.UnitDimensionless)
+	MeasureWaitTime                  = stats.Float64("go.sql/connections/wait_time", "Time blocked waiting for a new connection", stats.UnitMilliseconds)
+	MeasureIdleClosedConnections     = stats.Int64("go.sql/connections/idle_closed_connections", "Number of connections closed due to SetMaxIdleConns", stats.UnitDimensionless)
+	MeasureLifetimeClosedConnections = stats.Int64("go.sql/connections/lifetime_closed_connections", "Number of connections closed due to SetConnMaxLifetime", stats.UnitDimensionless)
+	MeasureIdleTimeClosedConnections = stats.Int64("go.sql/connections/idle_time_closed_connections", "Number of connections closed due to SetConnMaxIdleTime", stats.UnitDimensionless)
+)
@@ -0,0 +1,154 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opencensus.io/metric/metricdata"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.opencensus.io/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
+
+	"github.com/hashicorp/go-gin-gorm-opencensus/internal/ocotel"
+)
+
+// Span is the span of a query. Attributes and status use the OpenCensus types,
+// other tracing APIs convert them (see otelgormv2).
+type Span interface {
+	AddAttributes(attributes ...trace.Attribute)
+	SetStatus(status trace.Status)
+	SpanContext() trace.SpanContext
+	End()
+}
+
+// Tracer starts the spans of the queries.
+type Tracer interface {
+	// HasSpan reports whether the context holds a span to parent the query spans.
+	HasSpan(ctx context.Context) bool
+
+	// StartSpan starts the client span of an operation, a root span if root is set.
+	// The options only apply to root spans.
+	StartSpan(ctx context.Context, operation string, root bool, options trace.StartOptions) (context.Context, Span)
+}
+
+// Label is a dimension of the query measures.
+type Label struct {
+	Key   tag.Key
+	Value string
+}
+
+// Metrics records the measures of the queries.
+type Metrics interface {
+	// StartQuery returns the context of a query recording its measures with the labels.
+	StartQuery(ctx context.Context, labels []Label) context.Context
+
+	// EndQuery records the latency of a successful query, the span is nil if the query is not traced.
+	// The labels are the context tags, only recorded with the latency and the calls.
+	EndQuery(ctx context.Context, duration time.Duration, labels []Label, span Span)
+
+	// TruncatedAttributes records the number of span attributes truncated for a query.
+	TruncatedAttributes(ctx context.Context, truncated int64)
+
+	// NPlusOne records a query flagged by the QueryObserver.
+	NPlusOne(ctx context.Context)
+
+	// RecordDroppedTag records a value of the tag key dropped by the TagLimiter.
+	RecordDroppedTag(ctx context.Context, key tag.Key)
+}
+
+// openCensusTracer starts OpenCensus spans.
+type openCensusTracer struct{}
+
+func (openCensusTracer) HasSpan(ctx context.Context) bool {
+	return ocotel.FromContext(ctx) != nil
+}
+
+func (openCensusTracer) StartSpan(ctx context.Context, operation string, root bool, options trace.StartOptions) (context.Context, Span) {
+	var span *trace.Span
+
+	if root {
+		ctx, span = trace.StartSpan(
+			ctx,
+			fmt.Sprintf("gorm:%s", operation),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithSampler(options.Sampler),
+		)
+
+		return ctx, openCensusSpan{Span: span, bridged: ocotel.Bridged(ctx)}
+	}
+
+	parentSpan := trace.FromContext(ctx)
+
+	ctx, span = trace.StartSpan(ctx, fmt.Sprintf("gorm:%s", operation))
+	ctx = context.WithValue(ctx, parentSpanKey{}, parentSpan)
+
+	return ctx, openCensusSpan{Span: span, bridged: ocotel.Bridged(ctx)}
+}
+
+// openCensusSpan is an OpenCensus span, backed by an OpenTelemetry span if created by the bridge.
+type openCensusSpan struct {
+	*trace.Span
+
+	bridged oteltrace.Span
+}
+
+func (s openCensusSpan) SetStatus(status trace.Status) {
+	if s.bridged != nil {
+		ocotel.SetStatus(s.bridged, status)
+
+		return
+	}
+
+	s.Span.SetStatus(status)
+}
+
+// openCensusMetrics records OpenCensus measures, the labels are the tags of the context.
+type openCensusMetrics struct{}
+
+func (openCensusMetrics) StartQuery(ctx context.Context, labels []Label) context.Context {
+	ctx, _ = tag.New(ctx, mutators(labels)...)
+
+	return ctx
+}
+
+func mutators(labels []Label) []tag.Mutator {
+	mutators := make([]tag.Mutator, len(labels))
+	for i, label := range labels {
+		mutators[i] = tag.Upsert(label.Key, label.Value)
+	}
+
+	return mutators
+}
+
+func (openCensusMetrics) EndQuery(ctx context.Context, duration time.Duration, labels []Label, span Span) {
+	options := []stats.Options{
+		stats.WithTags(mutators(labels)...),
+		stats.WithMeasurements(
+			MeasureLatencyMs.M(float64(duration.Nanoseconds())/1e6),
+			MeasureQueryCount.M(1),
+		),
+	}
+
+	// Attach the span so that exporters supporting exemplars link the latency to the trace
+	if span != nil && span.SpanContext().IsSampled() {
+		options = append(options, stats.WithAttachments(metricdata.Attachments{
+			metricdata.AttachmentKeySpanContext: span.SpanContext(),
+		}))
+	}
+
+	_ = stats.RecordWithOptions(ctx, options...)
+}
+
+func (openCensusMetrics) TruncatedAttributes(ctx context.Context, truncated int64) {
+	stats.Record(ctx, MeasureTruncatedAttributes.M(truncated))
+}
+
+func (openCensusMetrics) NPlusOne(ctx context.Context) {
+	stats.Record(ctx, MeasureNPlusOne.M(1))
+}
+
+func (openCensusMetrics) RecordDroppedTag(ctx context.Context, key tag.Key) {
+	_ = stats.RecordWithTags(ctx, []tag.Mutator{tag.Upsert(LimitedTag, key.Name())}, MeasureDroppedTagValues.M(1))
+}
@@ -0,0 +1,317 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"go.opencensus.io/tag"
+	"go.opencensus.io/trace"
+
+	"github.com/hashicorp/go-gin-gorm-opencensus/internal/caller"
+	"github.com/hashicorp/go-gin-gorm-opencensus/internal/ocotel"
+	"github.com/hashicorp/go-gin-gorm-opencensus/internal/truncate"
+)
+
+// Statement adapts the statement of a gorm operation (a v1 scope or a v2 statement).
+type Statement interface {
+	// Context returns the context of the operation, nil if there is none.
+	Context() context.Context
+
+	// SetContext replaces the context of the operation.
+	SetContext(ctx context.Context)
+
+	Table() string
+
+	// Model returns the Go type name of the model, if any.
+	Model() string
+
+	SQL() string
+	Vars() []interface{}
+
+	// Err returns the error of the operation, if any.
+	Err() error
+
+	// IsRecordNotFound reports whether the error means that no record was found.
+	IsRecordNotFound(err error) bool
+
+	// AddError fails the operation.
+	AddError(err error)
+
+	RowsAffected() int64
+
+	// AddComment appends a comment to the statement of the operation.
+	AddComment(comment string)
+}
+
+// Callbacks instruments the gorm operations.
+type Callbacks struct {
+	config Config
+
+	// datadogAttributes are set to each span when following the Datadog conventions.
+	datadogAttributes []trace.Attribute
+}
+
+// New returns callbacks instrumenting a database of the given type (eg. mysql).
+func New(dbType string, opts ...Option) *Callbacks {
+	return NewFromConfig(dbType, NewConfig(opts...))
+}
+
+// NewFromConfig returns callbacks instrumenting a database of the given type with the configuration.
+// OpenCensus spans and measures are recorded unless Config.Tracer and Config.Metrics are set.
+func NewFromConfig(dbType string, config Config) *Callbacks {
+	c := &Callbacks{config: config}
+
+	if c.config.Tracer == nil {
+		c.config.Tracer = openCensusTracer{}
+	}
+
+	if c.config.Metrics == nil {
+		c.config.Metrics = openCensusMetrics{}
+	}
+
+	if c.config.QueryAttribute == "" {
+		c.config.QueryAttribute = ResourceNameAttribute
+	}
+
+	if c.config.Datadog != nil {
+		c.datadogAttributes = c.config.Datadog.Attributes(dbType)
+	}
+
+	if c.config.Bridge {
+		ocotel.InstallBridge(c.config.TracerProvider)
+	}
+
+	return c
+}
+
+// query is the state of an instrumented operation.
+type query struct {
+	span   Span
+	budget *truncate.Budget
+	start  time.Time
+}
+
+// queryKey holds the query of the callbacks, the last query started by any instrumentation
+// of the statement when callbacks is nil.
+type queryKey struct {
+	callbacks *Callbacks
+}
+
+type parentSpanKey struct{}
+
+// ParentSpanFromContext returns the span the query span of the context was started from.
+// Query spans end before gorm logs the query, their parent outlives them.
+func ParentSpanFromContext(ctx context.Context) (*trace.Span, bool) {
+	span, ok := ctx.Value(parentSpanKey{}).(*trace.Span)
+
+	return span, ok
+}
+
+// QueryDuration returns the time elapsed since the start of the query of the context.
+func QueryDuration(ctx context.Context) (time.Duration, bool) {
+	q, ok := ctx.Value(queryKey{}).(*query)
+	if !ok {
+		return 0, false
+	}
+
+	return time.Since(q.start), true
+}
+
+// Before starts the instrumentation of an operation.
+func (c *Callbacks) Before(s Statement, operation string) {
+	ctx := s.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	q := &query{start: time.Now()}
+
+	ctx = c.startTrace(ctx, s, q, operation)
+	ctx = c.startStats(ctx, s, operation)
+	ctx = context.WithValue(ctx, queryKey{callbacks: c}, q)
+	ctx = context.WithValue(ctx, queryKey{}, q)
+
+	s.SetContext(ctx)
+
+	if summary, ok := QuerySummaryFromContext(ctx); ok {
+		if err := summary.Start(ctx); err != nil {
+			s.AddError(err)
+		}
+	}
+
+	if c.config.Commenter != nil {
+		if comment := c.config.Commenter.Comment(ctx, q.span); comment != "" {
+			s.AddComment(comment)
+		}
+	}
+}
+
+// After ends the instrumentation of an operation.
+func (c *Callbacks) After(s Statement) {
+	ctx := s.Context()
+	if ctx == nil {
+		return
+	}
+
+	q, ok := ctx.Value(queryKey{callbacks: c}).(*query)
+	if !ok {
+		return
+	}
+
+	duration := time.Since(q.start)
+
+	c.endTrace(ctx, s, q)
+	c.endStats(ctx, s, q, duration)
+
+	if summary, ok := QuerySummaryFromContext(ctx); ok {
+		summary.End(duration, s.RowsAffected())
+	}
+
+	if c.config.NPlusOne != nil && c.config.NPlusOne.Observe(ctx, s.SQL()) {
+		c.config.Metrics.NPlusOne(ctx)
+	}
+}
+
+func (c *Callbacks) startTrace(ctx context.Context, s Statement, q *query, operation string) context.Context {
+	root := !c.config.Tracer.HasSpan(ctx)
+	if root && !c.config.AllowRoot {
+		return ctx
+	}
+
+	ctx, q.span = c.config.Tracer.StartSpan(ctx, operation, root, c.config.StartOptions)
+
+	attributes := make([]trace.Attribute, 0, len(c.config.DefaultAttributes)+len(c.datadogAttributes)+8)
+	attributes = append(attributes, c.config.DefaultAttributes...)
+	attributes = append(attributes, trace.StringAttribute(TableAttribute, s.Table()))
+
+	if model := s.Model(); model != "" {
+		attributes = append(attributes, trace.StringAttribute(ModelAttribute, model))
+	}
+
+	if c.config.Caller {
+		if frame, ok := caller.Find(); ok {
+			attributes = append(
+				attributes,
+				trace.StringAttribute(CodeFunctionAttribute, frame.Function),
+				trace.StringAttribute(CodeFilepathAttribute, frame.File),
+				trace.Int64Attribute(CodeLinenoAttribute, int64(frame.Line)),
+			)
+		}
+	}
+
+	q.budget = truncate.New(c.config.MaxStatementLength, c.config.MaxAttributesLength)
+
+	attributes = append(attributes, c.datadogAttributes...)
+	attributes = append(attributes, c.queryAttributes(q.budget, s.SQL())...)
+
+	q.span.AddAttributes(q.budget.Attributes(attributes)...)
+
+	return ctx
+}
+
+func (c *Callbacks) endTrace(ctx context.Context, s Statement, q *query) {
+	if q.span == nil {
+		return
+	}
+
+	sql := s.SQL()
+
+	// Add query to the span if requested
+	attributes := c.queryAttributes(q.budget, sql)
+
+	// Add bind parameters to the span if requested
+	if c.config.Parameters != nil {
+		attributes = append(attributes, trace.StringAttribute(ParametersAttribute, c.config.Parameters.FormatParameters(sql, s.Vars())))
+	}
+
+	q.span.AddAttributes(q.budget.Attributes(attributes)...)
+
+	if truncated := q.budget.Truncated(); truncated > 0 {
+		c.config.Metrics.TruncatedAttributes(ctx, truncated)
+	}
+
+	var status trace.Status
+
+	if err := s.Err(); err != nil {
+		if s.IsRecordNotFound(err) {
+			status.Code = trace.StatusCodeNotFound
+		} else {
+			status.Code = trace.StatusCodeUnknown
+		}
+
+		status.Message = err.Error()
+	}
+
+	q.span.SetStatus(status)
+
+	q.span.End()
+}
+
+// queryAttributes returns the span attributes recording the sql query, if requested.
+func (c *Callbacks) queryAttributes(budget *truncate.Budget, sql string) []trace.Attribute {
+	var attributes []trace.Attribute
+
+	if c.config.Datadog != nil {
+		// The sanitized query does not hold any value, it is always recorded
+		if sql != "" {
+			attributes = append(attributes, trace.StringAttribute(ResourceNameAttribute, budget.Statement(Fingerprint(sql))))
+		}
+
+		if c.config.Query {
+			attributes = append(attributes, trace.StringAttribute(SQLQueryAttribute, budget.Statement(sql)))
+		}
+
+		return attributes
+	}
+
+	if c.config.Query {
+		attributes = append(attributes, trace.StringAttribute(c.config.QueryAttribute, budget.Statement(sql)))
+	}
+
+	return attributes
+}
+
+func (c *Callbacks) startStats(ctx context.Context, s Statement, operation string) context.Context {
+	labels := []Label{
+		c.label(ctx, c.config.tagKey(c.config.OperationKey, Operation), operation),
+		c.label(ctx, c.config.tagKey(c.config.TableKey, Table), s.Table()),
+	}
+
+	if c.config.ModelTag {
+		labels = append(labels, c.label(ctx, c.config.tagKey(c.config.ModelKey, Model), s.Model()))
+	}
+
+	return c.config.Metrics.StartQuery(ctx, labels)
+}
+
+// label returns the label value bounded by the TagLimiter.
+func (c *Callbacks) label(ctx context.Context, key tag.Key, value string) Label {
+	if c.config.TagLimiter != nil {
+		var dropped bool
+		if value, dropped = c.config.TagLimiter.Limit(key, value); dropped {
+			c.config.Metrics.RecordDroppedTag(ctx, key)
+		}
+	}
+
+	return Label{Key: key, Value: value}
+}
+
+func (c *Callbacks) endStats(ctx context.Context, s Statement, q *query, duration time.Duration) {
+	if s.Err() != nil {
+		return
+	}
+
+	var labels []Label
+
+	if len(c.config.ContextTags) > 0 {
+		tags := tag.FromContext(ctx)
+
+		for _, key := range c.config.ContextTags {
+			if value, ok := tags.Value(key); ok {
+				labels = append(labels, c.label(ctx, key, value))
+			}
+		}
+	}
+
+	c.config.Metrics.EndQuery(ctx, duration, labels, q.span)
+}
@@ -0,0 +1,28 @@
+package core
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	fingerprintComments    = regexp.MustCompile(`(?s)/\*.*?\*/|--[^\n]*`)
+	fingerprintStrings     = regexp.MustCompile(`'(?:[^']|'')*'`)
+	fingerprintNumbers     = regexp.MustCompile(`\b\d+(?:\.\d+)?\b|\$\d+`)
+	fingerprintLists       = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+	fingerprintTuples      = regexp.MustCompile(`\(\?\)(?:\s*,\s*\(\?\))+`)
+	fingerprintWhitespaces = regexp.MustCompile(`\s+`)
+)
+
+// Fingerprint normalizes a sql query so that queries only differing by their
+// literal values, placeholder lists or comments share the same fingerprint.
+func Fingerprint(sql string) string {
+	sql = fingerprintComments.ReplaceAllString(sql, " ")
+	sql = fingerprintStrings.ReplaceAllString(sql, "?")
+	sql = fingerprintNumbers.ReplaceAllString(sql, "?")
+	sql = fingerprintLists.ReplaceAllString(sql, "(?)")
+	sql = fingerprintTuples.ReplaceAllString(sql, "(?)")
+	sql = fingerprintWhitespaces.ReplaceAllString(sql, " ")
+
+	return strings.TrimSpace(sql)
+}
@@ -0,0 +1,87 @@
+package core
+
+import (
+	"context"
+	"sync"
+
+	"go.opencensus.io/trace"
+)
+
+// DefaultNPlusOneThreshold is used when NPlusOneDetector.Threshold is not set.
+const DefaultNPlusOneThreshold = 10
+
+// NPlusOneAnnotation is the message of the annotation added to the request span.
+const NPlusOneAnnotation = "n_plus_one"
+
+// NPlusOneDetector flags queries repeated within a request, usually caused by
+// loading associations one record at a time.
+type NPlusOneDetector struct {
+	// Threshold is the number of times the same query fingerprint may be run
+	// within a request before being flagged.
+	Threshold int
+
+	// OnDetect is called once per fingerprint and request when the threshold is exceeded.
+	OnDetect func(ctx context.Context, fingerprint string, count int)
+}
+
+type queryFingerprints struct {
+	mu     sync.Mutex
+	span   RequestSpan
+	counts map[string]int
+}
+
+type queryFingerprintsKey struct{}
+
+// WithNPlusOneDetection seeds a request context for N+1 query detection.
+// The span found in the context is annotated when repeated queries are detected.
+func WithNPlusOneDetection(ctx context.Context) context.Context {
+	return WithNPlusOneDetectionSpan(ctx, trace.FromContext(ctx))
+}
+
+// WithNPlusOneDetectionSpan seeds a request context for N+1 query detection.
+// The span is annotated when repeated queries are detected.
+func WithNPlusOneDetectionSpan(ctx context.Context, span RequestSpan) context.Context {
+	return context.WithValue(ctx, queryFingerprintsKey{}, &queryFingerprints{
+		span:   span,
+		counts: make(map[string]int),
+	})
+}
+
+// Observe counts a query run in the request context and reports whether it was flagged as N+1.
+// Contexts not seeded with WithNPlusOneDetection are ignored.
+func (d NPlusOneDetector) Observe(ctx context.Context, sql string) bool {
+	fingerprints, ok := ctx.Value(queryFingerprintsKey{}).(*queryFingerprints)
+	if !ok || sql == "" {
+		return false
+	}
+
+	threshold := d.Threshold
+	if threshold <= 0 {
+		threshold = DefaultNPlusOneThreshold
+	}
+
+	fingerprint := Fingerprint(sql)
+
+	fingerprints.mu.Lock()
+	fingerprints.counts[fingerprint]++
+	count := fingerprints.counts[fingerprint]
+	fingerprints.mu.Unlock()
+
+	if count != threshold+1 {
+		return false
+	}
+
+	fingerprints.span.Annotate(
+		[]trace.Attribute{
+			trace.StringAttribute(FingerprintAttribute, fingerprint),
+			trace.Int64Attribute(QueryCountAttribute, int64(count)),
+		},
+		NPlusOneAnnotation,
+	)
+
+	if d.OnDetect != nil {
+		d.OnDetect(ctx, fingerprint, count)
+	}
+
+	return true
+}
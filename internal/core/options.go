@@ -0,0 +1,208 @@
+// Package core holds the instrumentation shared by ocgorm and ocgormv2.
+//
+// The gorm versions only differ by how a statement is accessed, see Statement.
+package core
+
+import (
+	"context"
+
+	"go.opencensus.io/tag"
+	"go.opencensus.io/trace"
+	"go.opentelemetry.io/otel/metric"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// Option allows for managing ocgorm configuration using functional options.
+type Option interface {
+	apply(c *Config)
+}
+
+// OptionFunc converts a regular function to an Option if it's definition is compatible.
+type OptionFunc func(c *Config)
+
+func (fn OptionFunc) apply(c *Config) {
+	fn(c)
+}
+
+// AllowRoot allows creating root spans in the absence of existing spans.
+type AllowRoot bool
+
+func (a AllowRoot) apply(c *Config) {
+	c.AllowRoot = bool(a)
+}
+
+// Query allows recording the sql queries in spans.
+type Query bool
+
+func (q Query) apply(c *Config) {
+	c.Query = bool(q)
+}
+
+// Caller allows recording the location of the code issuing the sql queries in spans.
+type Caller bool
+
+func (cl Caller) apply(c *Config) {
+	c.Caller = bool(cl)
+}
+
+// ModelTag allows tagging stats with the model name, see ModelViews.
+type ModelTag bool
+
+func (m ModelTag) apply(c *Config) {
+	c.ModelTag = bool(m)
+}
+
+// MaxStatementLength truncates sql queries recorded in spans to the given length.
+// Truncated queries end with a marker.
+type MaxStatementLength int
+
+func (m MaxStatementLength) apply(c *Config) {
+	c.MaxStatementLength = int(m)
+}
+
+// MaxAttributesLength limits the total length of the string attributes recorded on each span.
+// Values exceeding the budget are truncated or dropped.
+type MaxAttributesLength int
+
+func (m MaxAttributesLength) apply(c *Config) {
+	c.MaxAttributesLength = int(m)
+}
+
+// DefaultAttributes sets attributes to each span.
+type DefaultAttributes []trace.Attribute
+
+func (d DefaultAttributes) apply(c *Config) {
+	c.DefaultAttributes = []trace.Attribute(d)
+}
+
+// StartOptions configures the initial options applied to a span.
+func StartOptions(o trace.StartOptions) Option {
+	return OptionFunc(func(c *Config) {
+		c.StartOptions = o
+	})
+}
+
+// ParameterFormatter formats the bind parameters of a query, see ocgorm.ParameterRedaction.
+type ParameterFormatter interface {
+	FormatParameters(sql string, vars []interface{}) string
+}
+
+// QueryObserver observes the queries run within a request, see NPlusOneDetector.
+type QueryObserver interface {
+	// Observe reports whether the query was flagged.
+	Observe(ctx context.Context, sql string) bool
+}
+
+// Commenter returns the comment appended to the statement of the span, if any.
+type Commenter interface {
+	Comment(ctx context.Context, span Span) string
+}
+
+// TagLimiter bounds the values recorded for a tag key, see ocgorm.CardinalityLimiter.
+type TagLimiter interface {
+	// Limit returns the value to record for the tag key and whether the value was dropped.
+	Limit(key tag.Key, value string) (string, bool)
+}
+
+// Conventions returns attributes set to each span sent to a database of the given type, see ocgorm.DatadogConfig.
+type Conventions interface {
+	Attributes(dbType string) []trace.Attribute
+}
+
+// Config is the configuration of the instrumentation.
+type Config struct {
+	// Allow ocgorm to create root spans absence of existing spans or even context.
+	// Default is to not trace ocgorm calls if no existing parent span is found
+	// in context.
+	AllowRoot bool
+
+	// Allow recording of sql queries in spans.
+	// Only allow this if it is safe to have queries recorded with respect to
+	// security.
+	Query bool
+
+	// Allow recording of bind parameters in spans.
+	// Parameters are not recorded if nil.
+	Parameters ParameterFormatter
+
+	// Allow recording of the function, file and line issuing the query in spans.
+	Caller bool
+
+	// Tag stats with the Go type name of the model.
+	ModelTag bool
+
+	// Maximum length of the sql queries recorded in spans, zero means no limit.
+	MaxStatementLength int
+
+	// Maximum total length of the string attributes of each span, zero means no limit.
+	MaxAttributesLength int
+
+	// StartOptions are applied to the span started around each request.
+	//
+	// StartOptions.SpanKind will always be set to trace.SpanKindClient.
+	StartOptions trace.StartOptions
+
+	// DefaultAttributes will be set to each span as default.
+	DefaultAttributes []trace.Attribute
+
+	// Detect queries repeated within a request.
+	// Queries are not tracked if nil.
+	NPlusOne QueryObserver
+
+	// Append a comment to each statement so that database side
+	// logs can be correlated with traces.
+	// Statements are not commented if nil.
+	Commenter Commenter
+
+	// Follow the Datadog APM conventions for the span attributes.
+	// Conventions are not applied if nil.
+	Datadog Conventions
+
+	// Bound the number of distinct values of the tags recorded with the measures.
+	// Values are not limited if nil.
+	TagLimiter TagLimiter
+
+	// Install the OpenCensus to OpenTelemetry bridge with the tracer provider.
+	Bridge         bool
+	TracerProvider oteltrace.TracerProvider
+
+	// MeterProvider of the OpenTelemetry instruments, see otelgormv2.
+	MeterProvider metric.MeterProvider
+
+	// Tracer and Metrics record the queries, OpenCensus spans and measures if nil.
+	Tracer  Tracer
+	Metrics Metrics
+
+	// QueryAttribute is the span attribute recording the sql queries, ResourceNameAttribute if empty.
+	QueryAttribute string
+
+	// Tag keys recorded with the measures, the package keys if zero.
+	OperationKey    tag.Key
+	TableKey        tag.Key
+	ModelKey        tag.Key
+	DatabaseNameKey tag.Key
+
+	// Tag keys whose values are read from the context of the queries
+	// and recorded with the latency and calls measures.
+	ContextTags []tag.Key
+}
+
+// NewConfig returns the configuration set by the options.
+func NewConfig(opts ...Option) Config {
+	var c Config
+
+	for _, opt := range opts {
+		opt.apply(&c)
+	}
+
+	return c
+}
+
+// tagKey returns the configured key or the default one.
+func (c *Config) tagKey(key tag.Key, defaultKey tag.Key) tag.Key {
+	if key.Name() == "" {
+		return defaultKey
+	}
+
+	return key
+}
@@ -0,0 +1,143 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+)
+
+// RecordStats records the connection pool statistics of the database returned by db at the provided interval.
+func RecordStats(db func() (*sql.DB, error), interval time.Duration, name string, opts ...Option) (fnStop func()) {
+	var (
+		config    Config
+		closeOnce sync.Once
+		ctx       = context.Background()
+		ticker    = time.NewTicker(interval)
+		done      = make(chan struct{})
+	)
+
+	for _, opt := range opts {
+		opt.apply(&config)
+	}
+
+	databaseName := config.tagKey(config.DatabaseNameKey, DatabaseName)
+
+	// Previous snapshot for the per-interval rates
+	var (
+		previous     sql.DBStats
+		previousTime time.Time
+	)
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				sqlDB, err := db()
+				if err != nil {
+					return
+				}
+				dbStats := sqlDB.Stats()
+
+				if dbStats.OpenConnections == 0 { // We cleanup the ticker in the event that the database is unavailable
+					if err := sqlDB.Ping(); err != nil && strings.Contains(err.Error(), "database is closed") {
+						ticker.Stop()
+						return
+					}
+				}
+
+				measurements := []stats.Measurement{
+					MeasureOpenConnections.M(int64(dbStats.OpenConnections)),
+					MeasureIdleConnections.M(int64(dbStats.Idle)),
+					MeasureActiveConnections.M(int64(dbStats.InUse)),
+					MeasureWaitCount.M(dbStats.WaitCount),
+					MeasureWaitDuration.M(float64(dbStats.WaitDuration.Nanoseconds()) / 1e6),
+					MeasureIdleClosed.M(dbStats.MaxIdleClosed),
+					MeasureLifetimeClosed.M(dbStats.MaxLifetimeClosed),
+					MeasureMaxOpenConnections.M(int64(dbStats.MaxOpenConnections)),
+					MeasureIdleTimeClosed.M(dbStats.MaxIdleTimeClosed),
+					MeasurePoolSaturation.M(PoolSaturation(dbStats)),
+				}
+
+				// The first record counts the totals since the database was opened
+				waits := increase(dbStats.WaitCount, previous.WaitCount)
+				waited := time.Duration(increase(int64(dbStats.WaitDuration), int64(previous.WaitDuration)))
+
+				measurements = append(measurements,
+					MeasureWaits.M(waits),
+					MeasureWaitTime.M(float64(waited.Nanoseconds())/1e6),
+					MeasureIdleClosedConnections.M(increase(dbStats.MaxIdleClosed, previous.MaxIdleClosed)),
+					MeasureLifetimeClosedConnections.M(increase(dbStats.MaxLifetimeClosed, previous.MaxLifetimeClosed)),
+					MeasureIdleTimeClosedConnections.M(increase(dbStats.MaxIdleTimeClosed, previous.MaxIdleTimeClosed)),
+				)
+
+				now := time.Now()
+				if !previousTime.IsZero() {
+					rate, average := WaitStats(dbStats, previous, now.Sub(previousTime))
+
+					measurements = append(measurements,
+						MeasureWaitRate.M(rate),
+						MeasureAverageWait.M(float64(average.Nanoseconds())/1e6),
+					)
+				}
+				previous, previousTime = dbStats, now
+
+				stats.RecordWithTags(ctx,
+					[]tag.Mutator{tag.Upsert(databaseName, name)},
+					measurements...,
+				)
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() {
+		closeOnce.Do(func() {
+			close(done)
+		})
+	}
+}
+
+// PoolSaturation returns the ratio of connections in use to the maximum number of open connections,
+// zero if the number of open connections is not limited.
+func PoolSaturation(s sql.DBStats) float64 {
+	if s.MaxOpenConnections <= 0 {
+		return 0
+	}
+
+	return float64(s.InUse) / float64(s.MaxOpenConnections)
+}
+
+// WaitStats returns the number of connections waited for per second and the average time waited
+// for a connection between two sql.DBStats snapshots taken interval apart.
+// The average is zero if no connection was waited for.
+func WaitStats(current, previous sql.DBStats, interval time.Duration) (rate float64, average time.Duration) {
+	waits := increase(current.WaitCount, previous.WaitCount)
+	waited := time.Duration(increase(int64(current.WaitDuration), int64(previous.WaitDuration)))
+
+	if interval > 0 {
+		rate = float64(waits) / interval.Seconds()
+	}
+
+	if waits > 0 {
+		average = waited / time.Duration(waits)
+	}
+
+	return rate, average
+}
+
+// increase returns the increase of a sql.DBStats total since the previous value,
+// the whole total if it was reset (eg. the database was reopened).
+func increase(total, previous int64) int64 {
+	if total < previous {
+		return total
+	}
+
+	return total - previous
+}
@@ -0,0 +1,219 @@
+package core
+
+import (
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	"go.opencensus.io/metric/metricdata"
+)
+
+// PoolRegistry is a metric producer reading the connection pool statistics of
+// the registered databases at export time, exported by ocgorm.
+type PoolRegistry struct {
+	labelKey metricdata.LabelKey
+
+	mu        sync.Mutex
+	databases map[string]func() (*sql.DB, error)
+
+	// Start time of the cumulative metrics of each database, set when its statistics are first read
+	// so that registering the same database again does not reset them.
+	starts map[*sql.DB]time.Time
+}
+
+// NewPoolRegistry returns an empty registry, only the Stats option is used.
+func NewPoolRegistry(opts ...Option) *PoolRegistry {
+	var config Config
+
+	for _, opt := range opts {
+		opt.apply(&config)
+	}
+
+	return &PoolRegistry{
+		labelKey:  metricdata.LabelKey{Key: config.tagKey(config.DatabaseNameKey, DatabaseName).Name()},
+		databases: make(map[string]func() (*sql.DB, error)),
+		starts:    make(map[*sql.DB]time.Time),
+	}
+}
+
+// Add registers the database returned by db under the name, replacing the database of the same name.
+func (r *PoolRegistry) Add(name string, db func() (*sql.DB, error)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.databases[name] = db
+}
+
+// Remove deregisters the database of the name.
+func (r *PoolRegistry) Remove(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.databases, name)
+}
+
+// poolMetric describes a metric read from sql.DBStats.
+type poolMetric struct {
+	name        string
+	description string
+	unit        metricdata.Unit
+	metricType  metricdata.Type
+	value       func(s sql.DBStats) interface{}
+}
+
+// Pool metrics, named apart from the connection views so that both can be exported together.
+var poolMetrics = []poolMetric{
+	{
+		name:        "go.sql/db/pool/open",
+		description: "The number of open connections",
+		metricType:  metricdata.TypeGaugeInt64,
+		value:       func(s sql.DBStats) interface{} { return int64(s.OpenConnections) },
+	},
+	{
+		name:        "go.sql/db/pool/idle",
+		description: "The number of idle connections",
+		metricType:  metricdata.TypeGaugeInt64,
+		value:       func(s sql.DBStats) interface{} { return int64(s.Idle) },
+	},
+	{
+		name:        "go.sql/db/pool/active",
+		description: "The number of active connections",
+		metricType:  metricdata.TypeGaugeInt64,
+		value:       func(s sql.DBStats) interface{} { return int64(s.InUse) },
+	},
+	{
+		name:        "go.sql/db/pool/max_open",
+		description: "The maximum number of open connections",
+		metricType:  metricdata.TypeGaugeInt64,
+		value:       func(s sql.DBStats) interface{} { return int64(s.MaxOpenConnections) },
+	},
+	{
+		name:        "go.sql/db/pool/saturation",
+		description: "The ratio of connections in use to the maximum number of open connections",
+		metricType:  metricdata.TypeGaugeFloat64,
+		value:       func(s sql.DBStats) interface{} { return PoolSaturation(s) },
+	},
+	{
+		name:        "go.sql/db/pool/wait_count",
+		description: "The total number of connections waited for",
+		metricType:  metricdata.TypeCumulativeInt64,
+		value:       func(s sql.DBStats) interface{} { return s.WaitCount },
+	},
+	{
+		name:        "go.sql/db/pool/wait_duration",
+		description: "The total time blocked waiting for a new connection",
+		unit:        metricdata.UnitMilliseconds,
+		metricType:  metricdata.TypeCumulativeFloat64,
+		value:       func(s sql.DBStats) interface{} { return float64(s.WaitDuration.Nanoseconds()) / 1e6 },
+	},
+	{
+		name:        "go.sql/db/pool/idle_closed_count",
+		description: "The total number of connections closed due to SetMaxIdleConns",
+		metricType:  metricdata.TypeCumulativeInt64,
+		value:       func(s sql.DBStats) interface{} { return s.MaxIdleClosed },
+	},
+	{
+		name:        "go.sql/db/pool/lifetime_closed_count",
+		description: "The total number of connections closed due to SetConnMaxLifetime",
+		metricType:  metricdata.TypeCumulativeInt64,
+		value:       func(s sql.DBStats) interface{} { return s.MaxLifetimeClosed },
+	},
+	{
+		name:        "go.sql/db/pool/idle_time_closed_count",
+		description: "The total number of connections closed due to SetConnMaxIdleTime",
+		metricType:  metricdata.TypeCumulativeInt64,
+		value:       func(s sql.DBStats) interface{} { return s.MaxIdleTimeClosed },
+	},
+}
+
+// Read implements metricproducer.Producer.
+func (r *PoolRegistry) Read() []*metricdata.Metric {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.databases))
+	databases := make(map[string]func() (*sql.DB, error), len(r.databases))
+
+	for name, db := range r.databases {
+		names = append(names, name)
+		databases[name] = db
+	}
+	r.mu.Unlock()
+
+	sort.Strings(names)
+
+	now := time.Now()
+	starts := make(map[*sql.DB]time.Time, len(names))
+	metrics := make([]*metricdata.Metric, len(poolMetrics))
+
+	for i, m := range poolMetrics {
+		unit := m.unit
+		if unit == "" {
+			unit = metricdata.UnitDimensionless
+		}
+
+		metrics[i] = &metricdata.Metric{
+			Descriptor: metricdata.Descriptor{
+				Name:        m.name,
+				Description: m.description,
+				Unit:        unit,
+				Type:        m.metricType,
+				LabelKeys:   []metricdata.LabelKey{r.labelKey},
+			},
+		}
+	}
+
+	for _, name := range names {
+		sqlDB, err := databases[name]()
+		if err != nil || sqlDB == nil {
+			continue
+		}
+
+		start := r.start(sqlDB, now)
+		starts[sqlDB] = start
+
+		dbStats := sqlDB.Stats()
+
+		for i, m := range poolMetrics {
+			var point metricdata.Point
+
+			switch v := m.value(dbStats).(type) {
+			case int64:
+				point = metricdata.NewInt64Point(now, v)
+			case float64:
+				point = metricdata.NewFloat64Point(now, v)
+			}
+
+			series := &metricdata.TimeSeries{
+				LabelValues: []metricdata.LabelValue{metricdata.NewLabelValue(name)},
+				Points:      []metricdata.Point{point},
+			}
+
+			if m.metricType == metricdata.TypeCumulativeInt64 || m.metricType == metricdata.TypeCumulativeFloat64 {
+				series.StartTime = start
+			}
+
+			metrics[i].TimeSeries = append(metrics[i].TimeSeries, series)
+		}
+	}
+
+	// Forget the databases no longer registered
+	r.mu.Lock()
+	r.starts = starts
+	r.mu.Unlock()
+
+	return metrics
+}
+
+// start returns the start time of the cumulative metrics of the database, now if it was never read.
+func (r *PoolRegistry) start(db *sql.DB, now time.Time) time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	start, ok := r.starts[db]
+	if !ok {
+		start = now
+		r.starts[db] = start
+	}
+
+	return start
+}
@@ -0,0 +1,83 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"go.opencensus.io/trace"
+)
+
+// SQLCommentTags are request scoped values appended to statements as a sqlcommenter comment.
+type SQLCommentTags struct {
+	Route      string
+	Controller string
+	Action     string
+}
+
+type sqlCommentTagsKey struct{}
+
+// WithSQLCommentTags sets the sqlcommenter tags in the context, usually from an HTTP middleware.
+func WithSQLCommentTags(ctx context.Context, tags SQLCommentTags) context.Context {
+	return context.WithValue(ctx, sqlCommentTagsKey{}, tags)
+}
+
+// SQLCommentTagsFromContext returns the sqlcommenter tags stored in the context.
+func SQLCommentTagsFromContext(ctx context.Context) (SQLCommentTags, bool) {
+	tags, ok := ctx.Value(sqlCommentTagsKey{}).(SQLCommentTags)
+
+	return tags, ok
+}
+
+// Traceparent formats a W3C traceparent.
+func Traceparent(sc trace.SpanContext) string {
+	return fmt.Sprintf("00-%s-%s-%02x", sc.TraceID, sc.SpanID, uint32(sc.TraceOptions))
+}
+
+// FormatSQLComment formats a sqlcommenter comment from a W3C traceparent and the tags found in the context.
+// It returns an empty string if there is nothing to record.
+func FormatSQLComment(ctx context.Context, traceparent string, application string) string {
+	tags, _ := SQLCommentTagsFromContext(ctx)
+
+	fields := map[string]string{
+		"application": application,
+		"route":       tags.Route,
+		"controller":  tags.Controller,
+		"action":      tags.Action,
+		"traceparent": traceparent,
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key, value := range fields {
+		if value != "" {
+			keys = append(keys, key)
+		}
+	}
+
+	if len(keys) == 0 {
+		return ""
+	}
+
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = fmt.Sprintf("%s='%s'", key, strings.ReplaceAll(url.QueryEscape(fields[key]), "+", "%20"))
+	}
+
+	return "/*" + strings.Join(pairs, ",") + "*/"
+}
+
+// SQLCommenter comments statements with the trace context of the span, the application name
+// and the tags found in the context.
+type SQLCommenter string
+
+func (a SQLCommenter) Comment(ctx context.Context, span Span) string {
+	if span == nil {
+		return FormatSQLComment(ctx, "", string(a))
+	}
+
+	return FormatSQLComment(ctx, Traceparent(span.SpanContext()), string(a))
+}
@@ -0,0 +1,141 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.opencensus.io/trace"
+)
+
+// ErrQueryBudgetExceeded is returned for queries exceeding the request budget when QueryBudget.Fail is set.
+var ErrQueryBudgetExceeded = errors.New("ocgorm: query budget exceeded")
+
+// QueryBudgetExceededAnnotation is the message of the annotation added to the request span.
+const QueryBudgetExceededAnnotation = "query_budget_exceeded"
+
+// QueryBudget limits the number of queries run within a request.
+type QueryBudget struct {
+	// MaxQueries is the number of queries allowed, zero means no limit.
+	MaxQueries int
+
+	// Fail makes queries exceeding the budget fail with ErrQueryBudgetExceeded.
+	Fail bool
+
+	// OnExceeded is called once per request when the budget is exceeded (eg. to log it),
+	// after the QueryBudgetExceededAnnotation is added to the request span.
+	OnExceeded func(ctx context.Context, queries int)
+}
+
+// RequestSpan records the events and totals of a request, an OpenCensus *trace.Span
+// or an adapter of another tracing API.
+type RequestSpan interface {
+	Annotate(attributes []trace.Attribute, message string)
+	AddAttributes(attributes ...trace.Attribute)
+}
+
+// QuerySummary accumulates the queries run within a request
+// and records the totals on the request span.
+type QuerySummary struct {
+	mu       sync.Mutex
+	span     RequestSpan
+	budget   QueryBudget
+	queries  int
+	duration time.Duration
+	rows     int64
+}
+
+type querySummaryKey struct{}
+
+// WithQuerySummary seeds a request context with a query summary.
+// The summary is recorded on the span found in the context.
+func WithQuerySummary(ctx context.Context, budget QueryBudget) context.Context {
+	return WithQuerySummarySpan(ctx, budget, trace.FromContext(ctx))
+}
+
+// WithQuerySummarySpan seeds a request context with a query summary recorded on the span.
+func WithQuerySummarySpan(ctx context.Context, budget QueryBudget, span RequestSpan) context.Context {
+	return context.WithValue(ctx, querySummaryKey{}, &QuerySummary{
+		span:   span,
+		budget: budget,
+	})
+}
+
+// QuerySummaryFromContext returns the query summary of the request, if any.
+func QuerySummaryFromContext(ctx context.Context) (*QuerySummary, bool) {
+	summary, ok := ctx.Value(querySummaryKey{}).(*QuerySummary)
+
+	return summary, ok
+}
+
+// Start counts a query about to run.
+// It returns ErrQueryBudgetExceeded if the query exceeds a failing budget.
+func (s *QuerySummary) Start(ctx context.Context) error {
+	s.mu.Lock()
+	s.queries++
+	queries := s.queries
+	s.mu.Unlock()
+
+	if s.budget.MaxQueries <= 0 || queries <= s.budget.MaxQueries {
+		return nil
+	}
+
+	if queries == s.budget.MaxQueries+1 {
+		s.span.Annotate(
+			[]trace.Attribute{trace.Int64Attribute(QueryCountAttribute, int64(queries))},
+			QueryBudgetExceededAnnotation,
+		)
+
+		if s.budget.OnExceeded != nil {
+			s.budget.OnExceeded(ctx, queries)
+		}
+	}
+
+	if s.budget.Fail {
+		return ErrQueryBudgetExceeded
+	}
+
+	return nil
+}
+
+// End records the time spent and the rows affected by a query.
+func (s *QuerySummary) End(duration time.Duration, rows int64) {
+	s.mu.Lock()
+	s.duration += duration
+	if rows > 0 {
+		s.rows += rows
+	}
+	queries, total, totalRows := s.queries, s.duration, s.rows
+	s.mu.Unlock()
+
+	s.span.AddAttributes(
+		trace.Int64Attribute(QueryCountAttribute, int64(queries)),
+		trace.Float64Attribute(TotalTimeAttribute, float64(total.Nanoseconds())/1e6),
+		trace.Int64Attribute(RowsAttribute, totalRows),
+	)
+}
+
+// Queries returns the number of queries run so far.
+func (s *QuerySummary) Queries() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.queries
+}
+
+// Duration returns the total time spent in queries so far.
+func (s *QuerySummary) Duration() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.duration
+}
+
+// Rows returns the number of rows affected so far.
+func (s *QuerySummary) Rows() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.rows
+}
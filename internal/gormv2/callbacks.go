@@ -0,0 +1,131 @@
+// Package gormv2 registers the core instrumentation in the gorm v2 hook system.
+package gormv2
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/hashicorp/go-gin-gorm-opencensus/internal/core"
+)
+
+type callbacks struct {
+	core *core.Callbacks
+}
+
+// Register registers the callbacks of the instrumentation in gorm's hook system.
+// The callbacks are named after the prefix (eg. instrumentation:before_query) so that
+// several instrumentations can be registered on the same database.
+func Register(db *gorm.DB, prefix string, instrumentation *core.Callbacks) error {
+	c := &callbacks{core: instrumentation}
+
+	return errors.Join(
+		db.Callback().Create().Before("gorm:create").Register(prefix+":before_create", c.beforeCreate),
+		db.Callback().Create().After("gorm:create").Register(prefix+":after_create", c.afterCreate),
+		db.Callback().Query().Before("gorm:query").Register(prefix+":before_query", c.beforeQuery),
+		db.Callback().Query().After("gorm:query").Register(prefix+":after_query", c.afterQuery),
+		db.Callback().Row().Before("gorm:row").Register(prefix+":before_row_query", c.beforeRowQuery),
+		db.Callback().Row().After("gorm:row").Register(prefix+":after_row_query", c.afterRowQuery),
+		db.Callback().Update().Before("gorm:update").Register(prefix+":before_update", c.beforeUpdate),
+		db.Callback().Update().After("gorm:update").Register(prefix+":after_update", c.afterUpdate),
+		db.Callback().Delete().Before("gorm:delete").Register(prefix+":before_delete", c.beforeDelete),
+		db.Callback().Delete().After("gorm:delete").Register(prefix+":after_delete", c.afterDelete))
+}
+
+func (c *callbacks) before(db *gorm.DB, operation string) {
+	c.core.Before(statement{db}, operation)
+}
+
+func (c *callbacks) after(db *gorm.DB) {
+	c.core.After(statement{db})
+}
+
+// statement adapts a gorm statement to the core instrumentation.
+type statement struct {
+	db *gorm.DB
+}
+
+func (s statement) Context() context.Context {
+	return s.db.Statement.Context
+}
+
+func (s statement) SetContext(ctx context.Context) {
+	s.db.Statement.Context = ctx
+}
+
+func (s statement) Table() string {
+	return s.db.Statement.Table
+}
+
+// Model returns the Go type name of the statement model.
+func (s statement) Model() string {
+	if s.db.Statement.Schema == nil || s.db.Statement.Schema.ModelType == nil {
+		return ""
+	}
+
+	return s.db.Statement.Schema.ModelType.String()
+}
+
+func (s statement) SQL() string {
+	return s.db.Statement.SQL.String()
+}
+
+func (s statement) Vars() []interface{} {
+	return s.db.Statement.Vars
+}
+
+func (s statement) Err() error {
+	return s.db.Error
+}
+
+func (s statement) IsRecordNotFound(err error) bool {
+	return errors.Is(err, gorm.ErrRecordNotFound)
+}
+
+func (s statement) AddError(err error) {
+	_ = s.db.AddError(err)
+}
+
+func (s statement) RowsAffected() int64 {
+	return s.db.RowsAffected
+}
+
+// Name of the clause holding the sqlcommenter comment
+const sqlCommentClause = "ocgorm:sql_comment"
+
+func (s statement) AddComment(comment string) {
+	stmt := s.db.Statement
+
+	// Raw statements are already built at this point
+	if stmt.SQL.Len() > 0 {
+		stmt.SQL.WriteString(" " + comment)
+
+		return
+	}
+
+	stmt.Clauses[sqlCommentClause] = clause.Clause{Expression: clause.Expr{SQL: comment}}
+
+	for _, name := range stmt.BuildClauses {
+		if name == sqlCommentClause {
+			return
+		}
+	}
+
+	// BuildClauses is shared with the processor, so never append to it in place
+	buildClauses := make([]string, 0, len(stmt.BuildClauses)+1)
+	buildClauses = append(buildClauses, stmt.BuildClauses...)
+	stmt.BuildClauses = append(buildClauses, sqlCommentClause)
+}
+
+func (c *callbacks) beforeCreate(db *gorm.DB)   { c.before(db, "create") }
+func (c *callbacks) afterCreate(db *gorm.DB)    { c.after(db) }
+func (c *callbacks) beforeQuery(db *gorm.DB)    { c.before(db, "query") }
+func (c *callbacks) afterQuery(db *gorm.DB)     { c.after(db) }
+func (c *callbacks) beforeRowQuery(db *gorm.DB) { c.before(db, "row_query") }
+func (c *callbacks) afterRowQuery(db *gorm.DB)  { c.after(db) }
+func (c *callbacks) beforeUpdate(db *gorm.DB)   { c.before(db, "update") }
+func (c *callbacks) afterUpdate(db *gorm.DB)    { c.after(db) }
+func (c *callbacks) beforeDelete(db *gorm.DB)   { c.before(db, "delete") }
+func (c *callbacks) afterDelete(db *gorm.DB)    { c.after(db) }
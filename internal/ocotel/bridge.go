@@ -0,0 +1,116 @@
+// Package ocotel bridges OpenCensus spans and attributes to OpenTelemetry.
+package ocotel
+
+import (
+	"context"
+	"fmt"
+
+	"go.opencensus.io/trace"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/bridge/opencensus"
+	"go.opentelemetry.io/otel/codes"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// InstallBridge installs the OpenCensus bridge so that OpenCensus spans are created as
+// OpenTelemetry spans by the provider, the global provider if nil.
+//
+// OpenCensus and OpenTelemetry spans then share the same context and form a single trace.
+// OpenCensus samplers and exporters are not used anymore, the provider samples and exports the spans.
+func InstallBridge(tp oteltrace.TracerProvider) {
+	var opts []opencensus.TraceOption
+	if tp != nil {
+		opts = append(opts, opencensus.WithTracerProvider(tp))
+	}
+
+	opencensus.InstallTraceBridge(opts...)
+}
+
+// FromContext returns the span of the context, nil if there is none.
+//
+// The bridge returns a non recording span instead of nil when the context holds no span.
+func FromContext(ctx context.Context) *trace.Span {
+	span := trace.FromContext(ctx)
+	if span == nil || span.SpanContext().TraceID == (trace.TraceID{}) {
+		return nil
+	}
+
+	return span
+}
+
+// Bridged returns the OpenTelemetry span behind the OpenCensus span of the context,
+// nil if the span is not created by the bridge.
+func Bridged(ctx context.Context) oteltrace.Span {
+	span := FromContext(ctx)
+	if span == nil {
+		return nil
+	}
+
+	otelSpan := oteltrace.SpanFromContext(ctx)
+	if SpanContext(otelSpan.SpanContext()) != span.SpanContext() {
+		return nil
+	}
+
+	return otelSpan
+}
+
+// SetStatus sets the status of a span created by the bridge.
+//
+// The bridge passes OpenCensus codes through unchanged, which are not valid OpenTelemetry codes,
+// so any code but OK is set as an error.
+func SetStatus(span oteltrace.Span, status trace.Status) {
+	if status.Code == trace.StatusCodeOK {
+		return
+	}
+
+	span.SetStatus(codes.Error, status.Message)
+}
+
+// KeyValues converts OpenCensus attributes to OpenTelemetry attributes.
+func KeyValues(attributes []trace.Attribute) []attribute.KeyValue {
+	kvs := make([]attribute.KeyValue, 0, len(attributes))
+
+	for _, a := range attributes {
+		switch v := a.Value().(type) {
+		case string:
+			kvs = append(kvs, attribute.String(a.Key(), v))
+		case bool:
+			kvs = append(kvs, attribute.Bool(a.Key(), v))
+		case int64:
+			kvs = append(kvs, attribute.Int64(a.Key(), v))
+		case float64:
+			kvs = append(kvs, attribute.Float64(a.Key(), v))
+		default:
+			kvs = append(kvs, attribute.String(a.Key(), fmt.Sprint(v)))
+		}
+	}
+
+	return kvs
+}
+
+// Attributes converts OpenTelemetry attributes to OpenCensus attributes.
+func Attributes(kvs []attribute.KeyValue) []trace.Attribute {
+	attributes := make([]trace.Attribute, 0, len(kvs))
+
+	for _, kv := range kvs {
+		key := string(kv.Key)
+
+		switch kv.Value.Type() {
+		case attribute.BOOL:
+			attributes = append(attributes, trace.BoolAttribute(key, kv.Value.AsBool()))
+		case attribute.INT64:
+			attributes = append(attributes, trace.Int64Attribute(key, kv.Value.AsInt64()))
+		case attribute.FLOAT64:
+			attributes = append(attributes, trace.Float64Attribute(key, kv.Value.AsFloat64()))
+		default:
+			attributes = append(attributes, trace.StringAttribute(key, kv.Value.Emit()))
+		}
+	}
+
+	return attributes
+}
+
+// SpanContext converts an OpenTelemetry span context to an OpenCensus span context.
+func SpanContext(sc oteltrace.SpanContext) trace.SpanContext {
+	return opencensus.OTelSpanContextToOC(sc)
+}
@@ -0,0 +1,148 @@
+package ocotel
+
+import (
+	"context"
+
+	"go.opencensus.io/trace"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName is the instrumentation scope of converted spans.
+const InstrumentationName = "go.opencensus.io"
+
+// Exporter is an OpenCensus trace exporter feeding OpenTelemetry span processors,
+// for processes exporting OpenCensus spans without the bridge.
+type Exporter struct {
+	processor sdktrace.SpanProcessor
+	resource  *resource.Resource
+}
+
+// NewExporter returns an OpenCensus exporter sending spans to the processor.
+func NewExporter(processor sdktrace.SpanProcessor, res *resource.Resource) *Exporter {
+	return &Exporter{
+		processor: processor,
+		resource:  res,
+	}
+}
+
+// ExportSpan implements trace.Exporter.
+func (e *Exporter) ExportSpan(s *trace.SpanData) {
+	e.processor.OnEnd(ReadOnlySpan(s, e.resource))
+}
+
+// Shutdown flushes the pending spans and shuts the processor down.
+func (e *Exporter) Shutdown(ctx context.Context) error {
+	return e.processor.Shutdown(ctx)
+}
+
+// ReadOnlySpan converts an OpenCensus span.
+func ReadOnlySpan(s *trace.SpanData, res *resource.Resource) sdktrace.ReadOnlySpan {
+	stub := tracetest.SpanStub{
+		Name:        s.Name,
+		SpanContext: spanContext(s.SpanContext, false),
+		Parent: spanContext(trace.SpanContext{
+			TraceID:      s.TraceID,
+			SpanID:       s.ParentSpanID,
+			TraceOptions: s.TraceOptions,
+		}, s.HasRemoteParent),
+		SpanKind:             spanKind(s.SpanKind),
+		StartTime:            s.StartTime,
+		EndTime:              s.EndTime,
+		Attributes:           attributeMap(s.Attributes),
+		DroppedAttributes:    s.DroppedAttributeCount,
+		DroppedEvents:        s.DroppedAnnotationCount + s.DroppedMessageEventCount,
+		DroppedLinks:         s.DroppedLinkCount,
+		ChildSpanCount:       s.ChildSpanCount,
+		Resource:             res,
+		InstrumentationScope: instrumentation.Scope{Name: InstrumentationName},
+	}
+
+	if s.Status.Code != trace.StatusCodeOK {
+		stub.Status = sdktrace.Status{Code: codes.Error, Description: s.Status.Message}
+	}
+
+	for _, annotation := range s.Annotations {
+		stub.Events = append(stub.Events, sdktrace.Event{
+			Name:       annotation.Message,
+			Time:       annotation.Time,
+			Attributes: attributeMap(annotation.Attributes),
+		})
+	}
+
+	for _, event := range s.MessageEvents {
+		stub.Events = append(stub.Events, sdktrace.Event{
+			Name: "message",
+			Time: event.Time,
+			Attributes: []attribute.KeyValue{
+				attribute.Int64("message.id", event.MessageID),
+				attribute.Int64("message.uncompressed_size", event.UncompressedByteSize),
+				attribute.Int64("message.compressed_size", event.CompressedByteSize),
+			},
+		})
+	}
+
+	for _, link := range s.Links {
+		stub.Links = append(stub.Links, sdktrace.Link{
+			SpanContext: spanContext(trace.SpanContext{TraceID: link.TraceID, SpanID: link.SpanID}, true),
+			Attributes:  attributeMap(link.Attributes),
+		})
+	}
+
+	return stub.Snapshot()
+}
+
+// spanContext converts an OpenCensus span context.
+func spanContext(sc trace.SpanContext, remote bool) oteltrace.SpanContext {
+	var flags oteltrace.TraceFlags
+	if sc.IsSampled() {
+		flags = oteltrace.FlagsSampled
+	}
+
+	return oteltrace.NewSpanContext(oteltrace.SpanContextConfig{
+		TraceID:    oteltrace.TraceID(sc.TraceID),
+		SpanID:     oteltrace.SpanID(sc.SpanID),
+		TraceFlags: flags,
+		Remote:     remote,
+	})
+}
+
+// attributeMap converts the attributes of OpenCensus span data.
+func attributeMap(attributes map[string]interface{}) []attribute.KeyValue {
+	if len(attributes) == 0 {
+		return nil
+	}
+
+	converted := make([]attribute.KeyValue, 0, len(attributes))
+
+	for key, value := range attributes {
+		switch v := value.(type) {
+		case string:
+			converted = append(converted, attribute.String(key, v))
+		case bool:
+			converted = append(converted, attribute.Bool(key, v))
+		case int64:
+			converted = append(converted, attribute.Int64(key, v))
+		case float64:
+			converted = append(converted, attribute.Float64(key, v))
+		}
+	}
+
+	return converted
+}
+
+func spanKind(kind int) oteltrace.SpanKind {
+	switch kind {
+	case trace.SpanKindServer:
+		return oteltrace.SpanKindServer
+	case trace.SpanKindClient:
+		return oteltrace.SpanKindClient
+	default:
+		return oteltrace.SpanKindInternal
+	}
+}
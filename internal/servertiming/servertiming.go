@@ -0,0 +1,70 @@
+// Package servertiming reports the time spent in the database in a Server-Timing header.
+package servertiming
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/hashicorp/go-gin-gorm-opencensus/internal/core"
+)
+
+// Header is the response header carrying the time spent in the database.
+const Header = "Server-Timing"
+
+// Writer adds the Server-Timing header right before the headers are written.
+type Writer struct {
+	gin.ResponseWriter
+
+	summary *core.QuerySummary
+	done    bool
+}
+
+// NewWriter wraps a response writer to report the queries of the summary.
+func NewWriter(w gin.ResponseWriter, summary *core.QuerySummary) *Writer {
+	return &Writer{ResponseWriter: w, summary: summary}
+}
+
+// SetHeader adds the header unless the headers are already written.
+func (w *Writer) SetHeader() {
+	if w.done || w.ResponseWriter.Written() {
+		return
+	}
+
+	w.done = true
+
+	w.Header().Add(Header, format(w.summary.Queries(), w.summary.Duration()))
+}
+
+func (w *Writer) WriteHeaderNow() {
+	w.SetHeader()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *Writer) Write(data []byte) (int, error) {
+	w.SetHeader()
+
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *Writer) WriteString(s string) (int, error) {
+	w.SetHeader()
+
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *Writer) Flush() {
+	w.SetHeader()
+	w.ResponseWriter.Flush()
+}
+
+// format formats the Server-Timing metric of the database (eg. db;dur=12.3;desc="5 queries").
+func format(queries int, duration time.Duration) string {
+	desc := "queries"
+	if queries == 1 {
+		desc = "query"
+	}
+
+	return fmt.Sprintf(`db;dur=%.1f;desc="%d %s"`, float64(duration.Nanoseconds())/1e6, queries, desc)
+}
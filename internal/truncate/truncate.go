@@ -0,0 +1,143 @@
+// Package truncate limits the size of span attributes.
+package truncate
+
+import (
+	"unicode/utf8"
+
+	"go.opencensus.io/trace"
+)
+
+// Marker is appended to truncated values.
+const Marker = "...[truncated]"
+
+// Budget limits the size of the string attributes recorded on a single span.
+// A nil Budget does not limit anything.
+type Budget struct {
+	maxStatementLength int
+	maxLength          int
+
+	// Length of the value recorded for each attribute key
+	used map[string]int
+
+	// Values truncated by Statement, counted once recorded as attributes
+	statements map[string]bool
+
+	// Keys of the attributes counted as truncated, each is counted once
+	truncated map[string]bool
+}
+
+// New returns a budget for a span or nil if there are no limits.
+// Limits lower than or equal to zero are ignored.
+func New(maxStatementLength int, maxAttributesLength int) *Budget {
+	if maxStatementLength <= 0 && maxAttributesLength <= 0 {
+		return nil
+	}
+
+	return &Budget{
+		maxStatementLength: maxStatementLength,
+		maxLength:          maxAttributesLength,
+		used:               make(map[string]int),
+		statements:         make(map[string]bool),
+		truncated:          make(map[string]bool),
+	}
+}
+
+// Statement truncates a statement to the maximum statement length.
+// The truncation is counted once the statement is recorded with Attributes.
+func (b *Budget) Statement(statement string) string {
+	if b == nil || b.maxStatementLength <= 0 || len(statement) <= b.maxStatementLength {
+		return statement
+	}
+
+	truncated := truncate(statement, b.maxStatementLength)
+	b.statements[truncated] = true
+
+	return truncated
+}
+
+// Attributes truncates string attributes to fit in the remaining budget.
+// Attributes that do not fit at all are dropped.
+func (b *Budget) Attributes(attributes []trace.Attribute) []trace.Attribute {
+	if b == nil {
+		return attributes
+	}
+
+	limited := make([]trace.Attribute, 0, len(attributes))
+
+	for _, attribute := range attributes {
+		value, ok := attribute.Value().(string)
+		if !ok {
+			limited = append(limited, attribute)
+
+			continue
+		}
+
+		if b.statements[value] {
+			b.truncated[attribute.Key()] = true
+		}
+
+		if b.maxLength <= 0 {
+			limited = append(limited, attribute)
+
+			continue
+		}
+
+		// Values recorded again under the same key replace the previous one
+		remaining := b.maxLength
+		for key, length := range b.used {
+			if key != attribute.Key() {
+				remaining -= length
+			}
+		}
+
+		if len(value) > remaining {
+			b.truncated[attribute.Key()] = true
+
+			if remaining <= len(Marker) {
+				continue
+			}
+
+			value = truncate(value, remaining)
+			attribute = trace.StringAttribute(attribute.Key(), value)
+		}
+
+		b.used[attribute.Key()] = len(value)
+		limited = append(limited, attribute)
+	}
+
+	return limited
+}
+
+// Truncated returns the number of attributes truncated since the last call.
+func (b *Budget) Truncated() int64 {
+	if b == nil {
+		return 0
+	}
+
+	truncated := int64(len(b.truncated))
+	clear(b.truncated)
+
+	return truncated
+}
+
+// truncate shortens s to n bytes ending with the marker, only the start of the marker fits below its length.
+func truncate(s string, n int) string {
+	if n <= len(Marker) {
+		return Marker[:n]
+	}
+
+	return cut(s, n-len(Marker)) + Marker
+}
+
+// cut shortens s to at most n bytes without splitting a rune.
+func cut(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+
+	return s[:n]
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"go.opencensus.io/plugin/ochttp"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"github.com/hashicorp/go-gin-gorm-opencensus/pkg/occonfig"
+	"github.com/hashicorp/go-gin-gorm-opencensus/pkg/ocgin"
+	"github.com/hashicorp/go-gin-gorm-opencensus/pkg/ocgormv2"
+	"github.com/hashicorp/go-gin-gorm-opencensus/pkg/ocslog"
+)
+
+// Environment variables read by the example service besides the occonfig ones
+const (
+	envHTTPAddr   = "HTTP_ADDR"
+	envDBHost     = "DB_HOST"
+	envDBPort     = "DB_PORT"
+	envDBUser     = "DB_USER"
+	envDBPass     = "DB_PASS"
+	envDBName     = "DB_NAME"
+	envSQLitePath = "SQLITE_PATH"
+)
+
+const defaultHTTPAddr = ":8080"
+
+func main() {
+	logger := slog.New(ocslog.NewHandler(slog.NewJSONHandler(os.Stderr, nil)))
+
+	if err := run(logger); err != nil {
+		logger.Error("service failed", slog.String("error", err.Error()))
+
+		os.Exit(1)
+	}
+}
+
+func run(logger *slog.Logger) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	config, err := occonfig.FromEnv()
+	if err != nil {
+		return err
+	}
+
+	shutdownExporters, err := occonfig.Setup(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := shutdownExporters(ctx); err != nil {
+			logger.Error("flushing exporters", slog.String("error", err.Error()))
+		}
+	}()
+
+	db, name, err := openDatabase()
+	if err != nil {
+		return err
+	}
+
+	if err := ocgormv2.RegisterCallbacks(db, ocgormv2.Query(true), ocgormv2.Caller(true)); err != nil {
+		return err
+	}
+
+	defer ocgormv2.RecordStats(db, 5*time.Second, name)()
+
+	if err := db.AutoMigrate(&Todo{}); err != nil {
+		return err
+	}
+
+	router := gin.New()
+	router.Use(
+		ocgin.Logger(logger),
+		ocgin.Recovery(nil),
+		ocgin.Middleware(ocgin.ServerTiming(true)),
+	)
+
+	registerTodos(router, &todoHandler{db: db})
+
+	addr := os.Getenv(envHTTPAddr)
+	if addr == "" {
+		addr = defaultHTTPAddr
+	}
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           &ochttp.Handler{Handler: router},
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+
+	go func() {
+		logger.Info("listening", slog.String("addr", addr), slog.String("database", name))
+
+		errCh <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return server.Shutdown(shutdownCtx)
+}
+
+// openDatabase connects to MySQL using the DB_* variables,
+// or to a local SQLite database when DB_HOST is not set.
+func openDatabase() (*gorm.DB, string, error) {
+	host := os.Getenv(envDBHost)
+	if host == "" {
+		path := os.Getenv(envSQLitePath)
+		if path == "" {
+			path = "file::memory:?cache=shared"
+		}
+
+		db, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+
+		return db, "sqlite", err
+	}
+
+	port := os.Getenv(envDBPort)
+	if port == "" {
+		port = "3306"
+	}
+
+	name := os.Getenv(envDBName)
+	dsn := fmt.Sprintf(
+		"%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=UTC",
+		os.Getenv(envDBUser), os.Getenv(envDBPass), host, port, name,
+	)
+
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+
+	return db, name, err
+}
@@ -0,0 +1,307 @@
+package occonfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"contrib.go.opencensus.io/exporter/prometheus"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/trace"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/jaeger" //nolint:staticcheck // Jaeger thrift endpoints are still configured by JAEGER_ENDPOINT
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/exporters/zipkin"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/hashicorp/go-gin-gorm-opencensus/internal/ocotel"
+	"github.com/hashicorp/go-gin-gorm-opencensus/pkg/ocgorm"
+	"github.com/hashicorp/go-gin-gorm-opencensus/pkg/ocprometheus"
+)
+
+// Environment variables read by FromEnv
+const (
+	EnvServiceName         = "SERVICE_NAME"
+	EnvSamplingProbability = "TRACE_SAMPLING_PROBABILITY"
+	EnvJaegerEndpoint      = "JAEGER_ENDPOINT"
+	EnvJaegerAgentEndpoint = "JAEGER_AGENT_ENDPOINT"
+	EnvZipkinEndpoint      = "ZIPKIN_ENDPOINT"
+	EnvOTLPEndpoint        = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	EnvBridge              = "OPENCENSUS_BRIDGE"
+	EnvStdout              = "EXPORTER_STDOUT"
+	EnvPrometheusAddr      = "PROMETHEUS_ADDR"
+	EnvPrometheusNamespace = "PROMETHEUS_NAMESPACE"
+)
+
+// DefaultServiceName is used when no service name is configured.
+const DefaultServiceName = "go-gin-gorm-opencensus"
+
+// Config holds the exporter settings. Exporters are disabled when their endpoint is empty.
+type Config struct {
+	// ServiceName is recorded as the service.name resource of the spans.
+	ServiceName string
+
+	// SamplingProbability of new traces, nil keeps the default sampler.
+	SamplingProbability *float64
+
+	// JaegerEndpoint is the URL of a Jaeger collector (eg. http://localhost:14268/api/traces).
+	JaegerEndpoint string
+
+	// JaegerAgentEndpoint is the host:port of a Jaeger agent, used when JaegerEndpoint is empty.
+	JaegerAgentEndpoint string
+
+	// ZipkinEndpoint is the URL of a Zipkin collector (eg. http://localhost:9411/api/v2/spans).
+	ZipkinEndpoint string
+
+	// OTLPEndpoint is the base URL of an OTLP/HTTP receiver (eg. http://localhost:4318).
+	OTLPEndpoint string
+
+	// Bridge exports the spans through an OpenTelemetry tracer provider registered as the global
+	// provider, and installs the OpenCensus bridge so that OpenCensus and OpenTelemetry spans
+	// form a single trace. Otherwise the OpenCensus spans are exported by OpenCensus exporters
+	// and the global OpenTelemetry provider is left untouched.
+	Bridge bool
+
+	// Stdout writes spans and view data to the standard output.
+	Stdout bool
+
+	// PrometheusAddr is the address serving Prometheus metrics at ocprometheus.DefaultPath.
+	PrometheusAddr string
+
+	// PrometheusNamespace prefixes the Prometheus metric names.
+	PrometheusNamespace string
+
+	// Stats builds the ocgorm views registered by Setup (eg. with ExtraTags).
+	Stats ocgorm.StatsConfig
+}
+
+// FromEnv loads the configuration from environment variables.
+func FromEnv() (Config, error) {
+	c := Config{
+		ServiceName:         os.Getenv(EnvServiceName),
+		JaegerEndpoint:      os.Getenv(EnvJaegerEndpoint),
+		JaegerAgentEndpoint: os.Getenv(EnvJaegerAgentEndpoint),
+		ZipkinEndpoint:      os.Getenv(EnvZipkinEndpoint),
+		OTLPEndpoint:        os.Getenv(EnvOTLPEndpoint),
+		PrometheusAddr:      os.Getenv(EnvPrometheusAddr),
+		PrometheusNamespace: os.Getenv(EnvPrometheusNamespace),
+	}
+
+	if v := os.Getenv(EnvSamplingProbability); v != "" {
+		probability, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return c, fmt.Errorf("invalid %s: %w", EnvSamplingProbability, err)
+		}
+
+		c.SamplingProbability = &probability
+	}
+
+	if v := os.Getenv(EnvStdout); v != "" {
+		stdout, err := strconv.ParseBool(v)
+		if err != nil {
+			return c, fmt.Errorf("invalid %s: %w", EnvStdout, err)
+		}
+
+		c.Stdout = stdout
+	}
+
+	if v := os.Getenv(EnvBridge); v != "" {
+		bridge, err := strconv.ParseBool(v)
+		if err != nil {
+			return c, fmt.Errorf("invalid %s: %w", EnvBridge, err)
+		}
+
+		c.Bridge = bridge
+	}
+
+	return c, nil
+}
+
+// Setup registers the configured span exporters, through the OpenCensus bridge if Config.Bridge is set,
+// and registers the view exporters and the views of ocprometheus.Views.
+// The returned function flushes and unregisters the exporters, it should be called on exit.
+func Setup(ctx context.Context, c Config) (func(ctx context.Context) error, error) {
+	var shutdowns []func(ctx context.Context) error
+
+	shutdown := func(ctx context.Context) error {
+		var errs []error
+
+		for i := len(shutdowns) - 1; i >= 0; i-- {
+			errs = append(errs, shutdowns[i](ctx))
+		}
+
+		return errors.Join(errs...)
+	}
+
+	fail := func(err error) (func(ctx context.Context) error, error) {
+		return nil, errors.Join(err, shutdown(ctx))
+	}
+
+	views := ocprometheus.Views(c.Stats)
+
+	if err := view.Register(views...); err != nil {
+		return fail(err)
+	}
+
+	shutdowns = append(shutdowns, func(context.Context) error {
+		view.Unregister(views...)
+
+		return nil
+	})
+
+	exporters, err := c.spanExporters(ctx)
+	if err != nil {
+		return fail(err)
+	}
+
+	serviceName := c.ServiceName
+	if serviceName == "" {
+		serviceName = DefaultServiceName
+	}
+
+	res := resource.NewSchemaless(attribute.String("service.name", serviceName))
+
+	if c.Bridge {
+		options := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+
+		if c.SamplingProbability != nil {
+			options = append(options, sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(*c.SamplingProbability))))
+		}
+
+		for _, exporter := range exporters {
+			options = append(options, sdktrace.WithBatcher(exporter))
+		}
+
+		tp := sdktrace.NewTracerProvider(options...)
+
+		otel.SetTracerProvider(tp)
+		ocotel.InstallBridge(tp)
+
+		shutdowns = append(shutdowns, tp.Shutdown)
+	} else {
+		if c.SamplingProbability != nil {
+			trace.ApplyConfig(trace.Config{DefaultSampler: trace.ProbabilitySampler(*c.SamplingProbability)})
+		}
+
+		for _, exporter := range exporters {
+			exporter := ocotel.NewExporter(sdktrace.NewBatchSpanProcessor(exporter), res)
+
+			trace.RegisterExporter(exporter)
+
+			shutdowns = append(shutdowns, func(ctx context.Context) error {
+				trace.UnregisterExporter(exporter)
+
+				return exporter.Shutdown(ctx)
+			})
+		}
+	}
+
+	if c.Stdout {
+		exporter := newViewExporter(os.Stdout)
+
+		view.RegisterExporter(exporter)
+
+		shutdowns = append(shutdowns, func(context.Context) error {
+			view.UnregisterExporter(exporter)
+
+			return nil
+		})
+	}
+
+	if c.PrometheusAddr != "" {
+		serverShutdown, err := servePrometheus(c.PrometheusAddr, c.PrometheusNamespace, views)
+		if err != nil {
+			return fail(err)
+		}
+
+		shutdowns = append(shutdowns, serverShutdown)
+	}
+
+	return shutdown, nil
+}
+
+func (c Config) spanExporters(ctx context.Context) ([]sdktrace.SpanExporter, error) {
+	var exporters []sdktrace.SpanExporter
+
+	switch {
+	case c.JaegerEndpoint != "":
+		exporter, err := jaeger.New(jaeger.WithCollectorEndpoint(jaeger.WithEndpoint(c.JaegerEndpoint)))
+		if err != nil {
+			return nil, fmt.Errorf("jaeger exporter: %w", err)
+		}
+
+		exporters = append(exporters, exporter)
+	case c.JaegerAgentEndpoint != "":
+		host, port, err := net.SplitHostPort(c.JaegerAgentEndpoint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid jaeger agent endpoint: %w", err)
+		}
+
+		exporter, err := jaeger.New(jaeger.WithAgentEndpoint(jaeger.WithAgentHost(host), jaeger.WithAgentPort(port)))
+		if err != nil {
+			return nil, fmt.Errorf("jaeger exporter: %w", err)
+		}
+
+		exporters = append(exporters, exporter)
+	}
+
+	if c.ZipkinEndpoint != "" {
+		exporter, err := zipkin.New(c.ZipkinEndpoint)
+		if err != nil {
+			return nil, fmt.Errorf("zipkin exporter: %w", err)
+		}
+
+		exporters = append(exporters, exporter)
+	}
+
+	if c.OTLPEndpoint != "" {
+		exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(strings.TrimSuffix(c.OTLPEndpoint, "/")+"/v1/traces"))
+		if err != nil {
+			return nil, fmt.Errorf("otlp exporter: %w", err)
+		}
+
+		exporters = append(exporters, exporter)
+	}
+
+	if c.Stdout {
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, fmt.Errorf("stdout exporter: %w", err)
+		}
+
+		exporters = append(exporters, exporter)
+	}
+
+	return exporters, nil
+}
+
+func servePrometheus(addr string, namespace string, views []*view.View) (func(ctx context.Context) error, error) {
+	exporter, err := ocprometheus.NewExporter(prometheus.Options{Namespace: namespace}, views...)
+	if err != nil {
+		return nil, fmt.Errorf("prometheus exporter: %w", err)
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("prometheus listener: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(ocprometheus.DefaultPath, exporter)
+
+	server := &http.Server{Handler: mux}
+
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	return server.Shutdown, nil
+}
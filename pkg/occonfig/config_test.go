@@ -0,0 +1,145 @@
+package occonfig
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFromEnv(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       map[string]string
+		err       bool
+		exporters int
+		bridge    bool
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name: "invalid probability",
+			env:  map[string]string{EnvSamplingProbability: "often"},
+			err:  true,
+		},
+		{
+			name: "invalid stdout",
+			env:  map[string]string{EnvStdout: "maybe"},
+			err:  true,
+		},
+		{
+			name: "invalid bridge",
+			env:  map[string]string{EnvBridge: "maybe"},
+			err:  true,
+		},
+		{
+			name:   "bridge",
+			env:    map[string]string{EnvBridge: "true"},
+			bridge: true,
+		},
+		{
+			name: "empty endpoints",
+			env: map[string]string{
+				EnvJaegerEndpoint:      "",
+				EnvJaegerAgentEndpoint: "",
+				EnvZipkinEndpoint:      "",
+				EnvOTLPEndpoint:        "",
+				EnvStdout:              "false",
+			},
+		},
+		{
+			name: "all exporters",
+			env: map[string]string{
+				EnvJaegerEndpoint: "http://localhost:14268/api/traces",
+				EnvZipkinEndpoint: "http://localhost:9411/api/v2/spans",
+				EnvOTLPEndpoint:   "http://localhost:4318",
+				EnvStdout:         "true",
+			},
+			exporters: 4,
+		},
+		{
+			name: "jaeger agent",
+			env: map[string]string{
+				EnvJaegerAgentEndpoint: "localhost:6831",
+			},
+			exporters: 1,
+		},
+		{
+			name: "jaeger disabled",
+			env: map[string]string{
+				EnvZipkinEndpoint: "http://localhost:9411/api/v2/spans",
+				EnvOTLPEndpoint:   "http://localhost:4318",
+				EnvStdout:         "true",
+			},
+			exporters: 3,
+		},
+		{
+			name: "zipkin disabled",
+			env: map[string]string{
+				EnvJaegerEndpoint: "http://localhost:14268/api/traces",
+				EnvOTLPEndpoint:   "http://localhost:4318",
+				EnvStdout:         "true",
+			},
+			exporters: 3,
+		},
+		{
+			name: "otlp disabled",
+			env: map[string]string{
+				EnvJaegerEndpoint: "http://localhost:14268/api/traces",
+				EnvZipkinEndpoint: "http://localhost:9411/api/v2/spans",
+				EnvStdout:         "true",
+			},
+			exporters: 3,
+		},
+		{
+			name: "stdout disabled",
+			env: map[string]string{
+				EnvJaegerEndpoint: "http://localhost:14268/api/traces",
+				EnvZipkinEndpoint: "http://localhost:9411/api/v2/spans",
+				EnvOTLPEndpoint:   "http://localhost:4318",
+				EnvStdout:         "false",
+			},
+			exporters: 3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, key := range []string{
+				EnvServiceName, EnvSamplingProbability, EnvJaegerEndpoint, EnvJaegerAgentEndpoint, EnvZipkinEndpoint,
+				EnvOTLPEndpoint, EnvBridge, EnvStdout, EnvPrometheusAddr, EnvPrometheusNamespace,
+			} {
+				t.Setenv(key, test.env[key])
+			}
+
+			c, err := FromEnv()
+			if test.err {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if c.Bridge != test.bridge {
+				t.Errorf("expected bridge %t, got %t", test.bridge, c.Bridge)
+			}
+
+			exporters, err := c.spanExporters(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(exporters) != test.exporters {
+				t.Errorf("expected %d exporters, got %d", test.exporters, len(exporters))
+			}
+
+			for _, exporter := range exporters {
+				_ = exporter.Shutdown(context.Background())
+			}
+		})
+	}
+}
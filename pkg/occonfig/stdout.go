@@ -0,0 +1,54 @@
+package occonfig
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	"go.opencensus.io/stats/view"
+)
+
+// viewExporter writes view data to a writer as JSON lines.
+type viewExporter struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+func newViewExporter(w io.Writer) *viewExporter {
+	return &viewExporter{encoder: json.NewEncoder(w)}
+}
+
+type viewRow struct {
+	Tags map[string]string `json:"tags"`
+	Data interface{}       `json:"data"`
+}
+
+type viewRecord struct {
+	View  string    `json:"view"`
+	Start string    `json:"start"`
+	End   string    `json:"end"`
+	Rows  []viewRow `json:"rows"`
+}
+
+func (e *viewExporter) ExportView(vd *view.Data) {
+	record := viewRecord{
+		View:  vd.View.Name,
+		Start: vd.Start.String(),
+		End:   vd.End.String(),
+		Rows:  make([]viewRow, 0, len(vd.Rows)),
+	}
+
+	for _, row := range vd.Rows {
+		tags := make(map[string]string, len(row.Tags))
+		for _, t := range row.Tags {
+			tags[t.Key.Name()] = t.Value
+		}
+
+		record.Rows = append(record.Rows, viewRow{Tags: tags, Data: row.Data})
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	_ = e.encoder.Encode(record)
+}
@@ -0,0 +1,114 @@
+package ocgin
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.opencensus.io/plugin/ochttp"
+	"go.opencensus.io/tag"
+	oteltrace "go.opentelemetry.io/otel/trace"
+
+	"github.com/hashicorp/go-gin-gorm-opencensus/internal/ocotel"
+	"github.com/hashicorp/go-gin-gorm-opencensus/internal/servertiming"
+	"github.com/hashicorp/go-gin-gorm-opencensus/pkg/ocgorm"
+)
+
+// ServerTimingHeader is the response header carrying the time spent in the database.
+const ServerTimingHeader = servertiming.Header
+
+// Option allows for managing ocgin configuration using functional options.
+type Option interface {
+	apply(m *middleware)
+}
+
+// OptionFunc converts a regular function to an Option if it's definition is compatible.
+type OptionFunc func(m *middleware)
+
+func (fn OptionFunc) apply(m *middleware) {
+	fn(m)
+}
+
+// ServerTiming allows emitting a Server-Timing header with the time spent in the database.
+type ServerTiming bool
+
+func (s ServerTiming) apply(m *middleware) {
+	m.serverTiming = bool(s)
+}
+
+// QueryBudget limits the number of queries run by each request.
+func QueryBudget(b ocgorm.QueryBudget) Option {
+	return OptionFunc(func(m *middleware) {
+		m.queryBudget = b
+	})
+}
+
+// OpenTelemetryBridge installs the OpenCensus bridge so that the spans of ochttp and ocgorm are created by
+// the OpenTelemetry provider (the global one if nil) and nest with OpenTelemetry spans of the same context.
+// The bridge replaces the OpenCensus tracer of the whole process.
+func OpenTelemetryBridge(tp oteltrace.TracerProvider) Option {
+	return OptionFunc(func(m *middleware) {
+		m.bridge = true
+		m.tracerProvider = tp
+	})
+}
+
+type middleware struct {
+	// Emit a Server-Timing header with the time spent in the database.
+	serverTiming bool
+
+	// queryBudget is applied to the queries run by each request.
+	queryBudget ocgorm.QueryBudget
+
+	// Install the OpenCensus to OpenTelemetry bridge with the tracer provider.
+	bridge         bool
+	tracerProvider oteltrace.TracerProvider
+}
+
+// Middleware prepares the request context for the ocgorm and ocgormv2 instrumentation.
+//
+// The request span is expected to be started by an ochttp.Handler wrapping the Gin engine,
+// the middleware tags it with the matched route and seeds the context for
+// query summaries, N+1 query detection and sqlcommenter tags.
+func Middleware(opts ...Option) gin.HandlerFunc {
+	m := &middleware{}
+
+	for _, opt := range opts {
+		opt.apply(m)
+	}
+
+	if m.bridge {
+		ocotel.InstallBridge(m.tracerProvider)
+	}
+
+	return m.handle
+}
+
+func (m *middleware) handle(c *gin.Context) {
+	ctx := c.Request.Context()
+	route := c.FullPath()
+
+	ochttp.SetRoute(ctx, route)
+	ctx, _ = tag.New(ctx, tag.Upsert(ochttp.KeyServerRoute, route))
+
+	ctx = ocgorm.WithSQLCommentTags(ctx, ocgorm.SQLCommentTags{
+		Route:      route,
+		Controller: c.HandlerName(),
+	})
+	ctx = ocgorm.WithNPlusOneDetection(ctx)
+	ctx = ocgorm.WithQuerySummary(ctx, m.queryBudget)
+
+	c.Request = c.Request.WithContext(ctx)
+
+	if !m.serverTiming {
+		c.Next()
+
+		return
+	}
+
+	summary, _ := ocgorm.QuerySummaryFromContext(ctx)
+	writer := servertiming.NewWriter(c.Writer, summary)
+	c.Writer = writer
+
+	c.Next()
+
+	// Nothing was written by the handlers, Gin writes the headers after the middleware returns
+	writer.SetHeader()
+}
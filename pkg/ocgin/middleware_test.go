@@ -0,0 +1,82 @@
+package ocgin_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/hashicorp/go-gin-gorm-opencensus/pkg/ocgin"
+	"github.com/hashicorp/go-gin-gorm-opencensus/pkg/ocgormv2"
+)
+
+func TestServerTiming(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ocgormv2.RegisterCallbacks(db); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		handler gin.HandlerFunc
+		status  int
+	}{
+		{
+			name:    "body",
+			handler: func(c *gin.Context) { c.String(http.StatusOK, "ok") },
+			status:  http.StatusOK,
+		},
+		{
+			name:    "no content",
+			handler: func(c *gin.Context) { c.Status(http.StatusNoContent) },
+			status:  http.StatusNoContent,
+		},
+		{
+			name:    "aborted",
+			handler: func(c *gin.Context) { c.AbortWithStatus(http.StatusUnauthorized) },
+			status:  http.StatusUnauthorized,
+		},
+		{
+			name:    "nothing written",
+			handler: func(c *gin.Context) {},
+			status:  http.StatusOK,
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			engine := gin.New()
+			engine.Use(ocgin.Middleware(ocgin.ServerTiming(true)))
+			engine.GET("/", func(c *gin.Context) {
+				var one int
+				if err := db.WithContext(c.Request.Context()).Raw("SELECT 1").Scan(&one).Error; err != nil {
+					t.Fatal(err)
+				}
+
+				test.handler(c)
+			})
+
+			response := httptest.NewRecorder()
+			engine.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if response.Code != test.status {
+				t.Errorf("expected status %d, got %d", test.status, response.Code)
+			}
+
+			header := response.Header().Get(ocgin.ServerTimingHeader)
+			if !strings.HasPrefix(header, "db;dur=") || !strings.HasSuffix(header, `;desc="1 query"`) {
+				t.Errorf("expected a Server-Timing header for a single query, got %q", header)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package ocgin
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+	"go.opencensus.io/plugin/ochttp"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"go.opencensus.io/trace"
+)
+
+// PanicAnnotation is the message of the annotation added to the request span.
+const PanicAnnotation = "panic"
+
+// Attributes of the panic annotation
+const (
+	panicValueAttribute = "panic.value"
+	panicStackAttribute = "panic.stack"
+)
+
+// Recovery recovers from panics, annotates the request span with the panic
+// and delegates to the recovery handler (responding with a 500 status if nil).
+//
+// The span is owned and ended by ochttp.Handler, which sets its status from the response code.
+func Recovery(handle gin.RecoveryFunc) gin.HandlerFunc {
+	if handle == nil {
+		handle = func(c *gin.Context, _ interface{}) {
+			c.AbortWithStatus(http.StatusInternalServerError)
+		}
+	}
+
+	return func(c *gin.Context) {
+		defer func() {
+			if err := recover(); err != nil {
+				ctx := c.Request.Context()
+				span := trace.FromContext(ctx)
+				message := fmt.Sprint(err)
+
+				span.Annotate(
+					[]trace.Attribute{
+						trace.StringAttribute(panicValueAttribute, message),
+						trace.StringAttribute(panicStackAttribute, string(debug.Stack())),
+					},
+					PanicAnnotation,
+				)
+
+				stats.RecordWithTags(ctx,
+					[]tag.Mutator{tag.Upsert(ochttp.KeyServerRoute, c.FullPath())},
+					MeasurePanicCount.M(1),
+				)
+
+				handle(c, err)
+			}
+		}()
+
+		c.Next()
+	}
+}
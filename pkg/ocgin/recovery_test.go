@@ -0,0 +1,98 @@
+package ocgin_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opencensus.io/plugin/ochttp"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/trace"
+
+	"github.com/hashicorp/go-gin-gorm-opencensus/pkg/ocgin"
+)
+
+// spanRecorder collects the OpenCensus spans ended during a test.
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []*trace.SpanData
+}
+
+func (r *spanRecorder) ExportSpan(s *trace.SpanData) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.spans = append(r.spans, s)
+}
+
+func TestRecovery(t *testing.T) {
+	recorder := &spanRecorder{}
+	trace.RegisterExporter(recorder)
+	defer trace.UnregisterExporter(recorder)
+
+	if err := view.Register(ocgin.ServerPanicsView); err != nil {
+		t.Fatal(err)
+	}
+	defer view.Unregister(ocgin.ServerPanicsView)
+
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.Use(ocgin.Middleware(), ocgin.Recovery(nil))
+	engine.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	handler := &ochttp.Handler{
+		Handler:      engine,
+		StartOptions: trace.StartOptions{Sampler: trace.AlwaysSample()},
+	}
+
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	if response.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, response.Code)
+	}
+
+	if len(recorder.spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(recorder.spans))
+	}
+
+	span := recorder.spans[0]
+
+	if code := span.Attributes[ochttp.StatusCodeAttribute]; code != int64(http.StatusInternalServerError) {
+		t.Errorf("expected the span status code attribute to be %d, got %v", http.StatusInternalServerError, code)
+	}
+
+	if want := ochttp.TraceStatus(http.StatusInternalServerError, ""); span.Status.Code != want.Code {
+		t.Errorf("expected the span status code to be %d, got %d", want.Code, span.Status.Code)
+	}
+
+	var annotations []trace.Annotation
+
+	for _, annotation := range span.Annotations {
+		if annotation.Message == ocgin.PanicAnnotation {
+			annotations = append(annotations, annotation)
+		}
+	}
+
+	if len(annotations) != 1 {
+		t.Fatalf("expected 1 panic annotation, got %d", len(annotations))
+	}
+
+	if value := annotations[0].Attributes["panic.value"]; value != "boom" {
+		t.Errorf("expected the panic value to be recorded, got %v", value)
+	}
+
+	rows, err := view.RetrieveData(ocgin.ServerPanicsView.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rows) != 1 || rows[0].Data.(*view.CountData).Value != 1 {
+		t.Errorf("expected 1 panic recorded, got %v", rows)
+	}
+}
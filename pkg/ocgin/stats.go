@@ -0,0 +1,32 @@
+package ocgin
+
+import (
+	"go.opencensus.io/plugin/ochttp"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+// Measures
+var (
+	MeasurePanicCount = stats.Int64("gin/server/panics", "Number of panics recovered", stats.UnitDimensionless)
+)
+
+var (
+	ServerPanicsView = &view.View{
+		Name:        "gin/server/panics",
+		Description: "The number of panics recovered by route",
+		Measure:     MeasurePanicCount,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{ochttp.KeyServerRoute},
+	}
+
+	DefaultViews = []*view.View{ServerPanicsView}
+)
+
+// RegisterAllViews registers all ocgin views to enable collection of stats.
+func RegisterAllViews() {
+	if err := view.Register(DefaultViews...); err != nil {
+		panic(err)
+	}
+}
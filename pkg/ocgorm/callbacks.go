@@ -3,88 +3,103 @@ package ocgorm
 import (
 	"context"
 	"fmt"
-	"time"
 
 	"github.com/jinzhu/gorm"
-	"go.opencensus.io/stats"
-	"go.opencensus.io/tag"
 	"go.opencensus.io/trace"
+	oteltrace "go.opentelemetry.io/otel/trace"
+
+	"github.com/hashicorp/go-gin-gorm-opencensus/internal/core"
 )
 
 // Gorm scope keys
 var (
 	contextScopeKey = "_opencensusContext"
-	spanScopeKey    = "_opencensusSpan"
 )
 
 // Option allows for managing ocgorm configuration using functional options.
-type Option interface {
-	apply(c *callbacks)
-}
+// Options are shared with ocgormv2.
+type Option = core.Option
 
 // OptionFunc converts a regular function to an Option if it's definition is compatible.
-type OptionFunc func(c *callbacks)
-
-func (fn OptionFunc) apply(c *callbacks) {
-	fn(c)
-}
+type OptionFunc = core.OptionFunc
 
 // AllowRoot allows creating root spans in the absence of existing spans.
-type AllowRoot bool
-
-func (a AllowRoot) apply(c *callbacks) {
-	c.allowRoot = bool(a)
-}
+type AllowRoot = core.AllowRoot
 
 // Query allows recording the sql queries in spans.
-type Query bool
+type Query = core.Query
 
-func (q Query) apply(c *callbacks) {
-	c.query = bool(q)
-}
+// Caller allows recording the location of the code issuing the sql queries in spans.
+type Caller = core.Caller
+
+// ModelTag allows tagging stats with the model name, see ModelViews.
+type ModelTag = core.ModelTag
+
+// MaxStatementLength truncates sql queries recorded in spans to the given length.
+// Truncated queries end with a marker.
+type MaxStatementLength = core.MaxStatementLength
+
+// MaxAttributesLength limits the total length of the string attributes recorded on each span.
+// Values exceeding the budget are truncated or dropped.
+type MaxAttributesLength = core.MaxAttributesLength
+
+// DefaultAttributes sets attributes to each span.
+type DefaultAttributes = core.DefaultAttributes
 
 // StartOptions configures the initial options applied to a span.
 func StartOptions(o trace.StartOptions) Option {
-	return OptionFunc(func(c *callbacks) {
-		c.startOptions = o
-	})
+	return core.StartOptions(o)
 }
 
-// DefaultAttributes sets attributes to each span.
-type DefaultAttributes []trace.Attribute
+// Parameters allows recording the bind parameters of sql queries in spans.
+func Parameters(r ParameterRedaction) Option {
+	return OptionFunc(func(c *core.Config) {
+		c.Parameters = r
+	})
+}
 
-func (d DefaultAttributes) apply(c *callbacks) {
-	c.defaultAttributes = []trace.Attribute(d)
+// NPlusOne allows detecting queries repeated within a request seeded with WithNPlusOneDetection.
+func NPlusOne(d NPlusOneDetector) Option {
+	return OptionFunc(func(c *core.Config) {
+		c.NPlusOne = d
+	})
 }
 
-type callbacks struct {
-	// Allow ocgorm to create root spans absence of existing spans or even context.
-	// Default is to not trace ocgorm calls if no existing parent span is found
-	// in context.
-	allowRoot bool
+// SQLCommenter appends a sqlcommenter comment with the trace context and the
+// application name to each statement.
+func SQLCommenter(application string) Option {
+	return OptionFunc(func(c *core.Config) {
+		c.Commenter = core.SQLCommenter(application)
+	})
+}
 
-	// Allow recording of sql queries in spans.
-	// Only allow this if it is safe to have queries recorded with respect to
-	// security.
-	query bool
+// DatadogConventions sets the span attributes expected by Datadog APM: span.type, service.name,
+// db.type, out.host and out.port. resource.name is set to the sanitized query (see Fingerprint)
+// and the raw query, if recorded, moves to sql.query.
+func DatadogConventions(d DatadogConfig) Option {
+	return OptionFunc(func(c *core.Config) {
+		c.Datadog = d
+	})
+}
 
-	// startOptions are applied to the span started around each request.
-	//
-	// StartOptions.SpanKind will always be set to trace.SpanKindClient.
-	startOptions trace.StartOptions
+// OpenTelemetryBridge installs the OpenCensus bridge so that ocgorm spans are created by the
+// OpenTelemetry provider (the global one if nil) and nest with OpenTelemetry spans of the same context.
+// The bridge replaces the OpenCensus tracer of the whole process.
+func OpenTelemetryBridge(tp oteltrace.TracerProvider) Option {
+	return OptionFunc(func(c *core.Config) {
+		c.Bridge = true
+		c.TracerProvider = tp
+	})
+}
 
-	// DefaultAttributes will be set to each span as default.
-	defaultAttributes []trace.Attribute
+type callbacks struct {
+	core *core.Callbacks
 }
 
 // RegisterCallbacks registers the necessary callbacks in Gorm's hook system for instrumentation.
 func RegisterCallbacks(db *gorm.DB, opts ...Option) {
 	c := &callbacks{
-		defaultAttributes: []trace.Attribute{},
-	}
-
-	for _, opt := range opts {
-		opt.apply(c)
+		core: core.New(db.Dialect().GetName(), opts...),
 	}
 
 	db.Callback().Create().Before("gorm:create").Register("instrumentation:before_create", c.beforeCreate)
@@ -100,138 +115,93 @@ func RegisterCallbacks(db *gorm.DB, opts ...Option) {
 }
 
 func (c *callbacks) before(scope *gorm.Scope, operation string) {
-	rctx, _ := scope.Get(contextScopeKey)
-	ctx, ok := rctx.(context.Context)
-	if !ok || ctx == nil {
-		ctx = context.Background()
-	}
-
-	ctx = c.startTrace(ctx, scope, operation)
-	ctx = c.startStats(ctx, scope, operation)
-
-	scope.Set(contextScopeKey, ctx)
+	c.core.Before(&statement{scope: scope, operation: operation}, operation)
 }
 
 func (c *callbacks) after(scope *gorm.Scope) {
-	c.endTrace(scope)
-	c.endStats(scope)
+	c.core.After(&statement{scope: scope})
 }
 
-func (c *callbacks) startTrace(ctx context.Context, scope *gorm.Scope, operation string) context.Context {
-	// Context is missing, but we allow root spans to be created
-	if ctx == nil {
-		ctx = context.Background()
-	}
-
-	parentSpan := trace.FromContext(ctx)
-	if parentSpan == nil && !c.allowRoot {
-		return ctx
-	}
-
-	var span *trace.Span
-
-	if parentSpan == nil {
-		ctx, span = trace.StartSpan(
-			context.Background(),
-			fmt.Sprintf("gorm:%s", operation),
-			trace.WithSpanKind(trace.SpanKindClient),
-			trace.WithSampler(c.startOptions.Sampler),
-		)
-	} else {
-		_, span = trace.StartSpan(ctx, fmt.Sprintf("gorm:%s", operation))
-	}
-
-	attributes := append(
-		c.defaultAttributes,
-		trace.StringAttribute(TableAttribute, scope.TableName()),
-	)
-
-	if c.query {
-		attributes = append(attributes, trace.StringAttribute(ResourceNameAttribute, scope.SQL))
-	}
-
-	span.AddAttributes(attributes...)
+// statement adapts a gorm scope to the core instrumentation.
+type statement struct {
+	scope     *gorm.Scope
+	operation string
+}
 
-	scope.Set(spanScopeKey, span)
+func (s *statement) Context() context.Context {
+	rctx, _ := s.scope.Get(contextScopeKey)
+	ctx, _ := rctx.(context.Context)
 
 	return ctx
 }
 
-func (c *callbacks) endTrace(scope *gorm.Scope) {
-	rspan, ok := scope.Get(spanScopeKey)
-	if !ok {
-		return
-	}
+func (s *statement) SetContext(ctx context.Context) {
+	s.scope.Set(contextScopeKey, ctx)
+}
 
-	span, ok := rspan.(*trace.Span)
-	if !ok {
-		return
-	}
+func (s *statement) Table() string {
+	return s.scope.TableName()
+}
 
-	// Add query to the span if requested
-	if c.query {
-		span.AddAttributes(trace.StringAttribute(ResourceNameAttribute, scope.SQL))
+// Model returns the Go type name of the scope model.
+func (s *statement) Model() string {
+	modelType := s.scope.GetModelStruct().ModelType
+	if modelType == nil {
+		return ""
 	}
 
-	var status trace.Status
+	return modelType.String()
+}
+
+func (s *statement) SQL() string {
+	return s.scope.SQL
+}
 
-	if scope.HasError() {
-		err := scope.DB().Error
-		if gorm.IsRecordNotFoundError(err) {
-			status.Code = trace.StatusCodeNotFound
-		} else {
-			status.Code = trace.StatusCodeUnknown
-		}
+func (s *statement) Vars() []interface{} {
+	return s.scope.SQLVars
+}
 
-		status.Message = err.Error()
+func (s *statement) Err() error {
+	if !s.scope.HasError() {
+		return nil
 	}
 
-	span.SetStatus(status)
-
-	span.End()
+	return s.scope.DB().Error
 }
 
-var (
-	queryStartPropagator, _ = tag.NewKey("sql.query_start")
-)
+func (s *statement) IsRecordNotFound(err error) bool {
+	return gorm.IsRecordNotFoundError(err)
+}
 
-func (c *callbacks) startStats(ctx context.Context, scope *gorm.Scope, operation string) context.Context {
-	ctx, _ = tag.New(ctx,
-		tag.Upsert(Operation, operation),
-		tag.Upsert(Table, scope.TableName()),
-		tag.Upsert(queryStartPropagator, time.Now().UTC().Format(time.RFC3339Nano)),
-	)
+func (s *statement) AddError(err error) {
+	_ = s.scope.Err(err)
+}
 
-	return ctx
+func (s *statement) RowsAffected() int64 {
+	return s.scope.DB().RowsAffected
 }
 
-func (c *callbacks) endStats(scope *gorm.Scope) {
-	if scope.HasError() {
-		return
-	}
+// Gorm scope keys holding extra SQL appended to the statement of each operation
+var sqlOptionScopeKeys = map[string]string{
+	"create":    "gorm:insert_option",
+	"query":     "gorm:query_option",
+	"row_query": "gorm:query_option",
+	"update":    "gorm:update_option",
+	"delete":    "gorm:delete_option",
+}
 
-	rctx, _ := scope.Get(contextScopeKey)
-	ctx, ok := rctx.(context.Context)
-	if !ok || ctx == nil {
+func (s *statement) AddComment(comment string) {
+	key, ok := sqlOptionScopeKeys[s.operation]
+	if !ok {
 		return
 	}
 
-	tags := tag.FromContext(ctx)
-	ctx, _ = tag.New(ctx, tag.Delete(queryStartPropagator))
-	queryStartNS, exists := tags.Value(queryStartPropagator)
-
-	if exists {
-		queryStart, err := time.Parse(time.RFC3339Nano, queryStartNS)
-		if err != nil {
-			return
-		}
-
-		timeSpentMs := float64(time.Since(queryStart).Nanoseconds()) / 1e6
-
-		stats.Record(ctx, MeasureLatencyMs.M(timeSpentMs))
+	// Keep options set by the caller (eg. FOR UPDATE)
+	if option, ok := s.scope.Get(key); ok {
+		comment = fmt.Sprintf("%v %s", option, comment)
 	}
 
-	stats.Record(ctx, MeasureQueryCount.M(1))
+	s.scope.Set(key, comment)
 }
 
 func (c *callbacks) beforeCreate(scope *gorm.Scope)   { c.before(scope, "create") }
@@ -0,0 +1,88 @@
+package ocgorm
+
+import (
+	"regexp"
+	"sync"
+
+	"go.opencensus.io/tag"
+
+	"github.com/hashicorp/go-gin-gorm-opencensus/internal/core"
+)
+
+// OverflowTagValue replaces the tag values exceeding the limit of a CardinalityLimiter.
+const OverflowTagValue = "other"
+
+// NormalizationRule rewrites the tag values matching Pattern with Replacement,
+// eg. events_\d+ to events_N for sharded tables. Replacement follows regexp.Regexp.ReplaceAllString.
+type NormalizationRule struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+// CardinalityLimiter caps the number of distinct values recorded per tag key (eg. sql.table)
+// so that dynamic table names do not blow up the cardinality of the views.
+//
+// Values are normalized by the rules first, the values seen once the limit is reached are
+// replaced by OverflowTagValue and counted by MeasureDroppedTagValues.
+type CardinalityLimiter struct {
+	// MaxValues is the number of distinct values kept per tag key, zero means no limit.
+	MaxValues int
+
+	// Rules are applied in order to each value.
+	Rules []NormalizationRule
+
+	mu     sync.Mutex
+	values map[tag.Key]map[string]struct{}
+}
+
+// NewCardinalityLimiter returns a limiter keeping maxValues distinct values per tag key.
+func NewCardinalityLimiter(maxValues int, rules ...NormalizationRule) *CardinalityLimiter {
+	return &CardinalityLimiter{
+		MaxValues: maxValues,
+		Rules:     rules,
+	}
+}
+
+// Limit returns the value to record for the tag key and whether the value was dropped.
+// Dropped values are counted by the instrumentation, see MeasureDroppedTagValues.
+func (l *CardinalityLimiter) Limit(key tag.Key, value string) (string, bool) {
+	for _, rule := range l.Rules {
+		value = rule.Pattern.ReplaceAllString(value, rule.Replacement)
+	}
+
+	if l.MaxValues <= 0 {
+		return value, false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.values == nil {
+		l.values = make(map[tag.Key]map[string]struct{})
+	}
+
+	values, ok := l.values[key]
+	if !ok {
+		values = make(map[string]struct{})
+		l.values[key] = values
+	}
+
+	if _, ok := values[value]; ok {
+		return value, false
+	}
+
+	if len(values) < l.MaxValues {
+		values[value] = struct{}{}
+
+		return value, false
+	}
+
+	return OverflowTagValue, true
+}
+
+// CardinalityLimit bounds the values of the tags recorded with the measures (operation, table and model).
+func CardinalityLimit(l *CardinalityLimiter) Option {
+	return OptionFunc(func(c *core.Config) {
+		c.TagLimiter = l
+	})
+}
@@ -0,0 +1,85 @@
+package ocgorm
+
+import (
+	"regexp"
+	"testing"
+
+	"go.opencensus.io/tag"
+)
+
+func TestCardinalityLimiter(t *testing.T) {
+	table := tag.MustNewKey("sql.table")
+	operation := tag.MustNewKey("sql.operation")
+
+	type limit struct {
+		key     tag.Key
+		value   string
+		want    string
+		dropped bool
+	}
+
+	tests := []struct {
+		name      string
+		maxValues int
+		rules     []NormalizationRule
+		limits    []limit
+	}{
+		{
+			name:      "no limit",
+			maxValues: 0,
+			limits: []limit{
+				{key: table, value: "users", want: "users"},
+				{key: table, value: "posts", want: "posts"},
+			},
+		},
+		{
+			name:      "overflow",
+			maxValues: 2,
+			limits: []limit{
+				{key: table, value: "users", want: "users"},
+				{key: table, value: "posts", want: "posts"},
+				{key: table, value: "tags", want: OverflowTagValue, dropped: true},
+				{key: table, value: "users", want: "users"},
+				{key: operation, value: "query", want: "query"},
+			},
+		},
+		{
+			name:      "normalized values share a slot",
+			maxValues: 2,
+			rules: []NormalizationRule{
+				{Pattern: regexp.MustCompile(`^events_\d+$`), Replacement: "events_N"},
+			},
+			limits: []limit{
+				{key: table, value: "events_1", want: "events_N"},
+				{key: table, value: "events_2", want: "events_N"},
+				{key: table, value: "users", want: "users"},
+				{key: table, value: "posts", want: OverflowTagValue, dropped: true},
+				{key: table, value: "events_3", want: "events_N"},
+			},
+		},
+		{
+			name:      "rules applied in order",
+			maxValues: 0,
+			rules: []NormalizationRule{
+				{Pattern: regexp.MustCompile(`\d+`), Replacement: "N"},
+				{Pattern: regexp.MustCompile(`^tenant_N_`), Replacement: ""},
+			},
+			limits: []limit{
+				{key: table, value: "tenant_42_users", want: "users"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			limiter := NewCardinalityLimiter(test.maxValues, test.rules...)
+
+			for _, l := range test.limits {
+				value, dropped := limiter.Limit(l.key, l.value)
+				if value != l.want || dropped != l.dropped {
+					t.Errorf("%s=%s: expected (%q, %t), got (%q, %t)", l.key.Name(), l.value, l.want, l.dropped, value, dropped)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,11 @@
+package ocgorm
+
+import (
+	"github.com/hashicorp/go-gin-gorm-opencensus/internal/core"
+)
+
+// Fingerprint normalizes a sql query so that queries only differing by their
+// literal values, placeholder lists or comments share the same fingerprint.
+func Fingerprint(sql string) string {
+	return core.Fingerprint(sql)
+}
@@ -0,0 +1,29 @@
+package ocgorm
+
+import (
+	"context"
+
+	"github.com/hashicorp/go-gin-gorm-opencensus/internal/core"
+)
+
+// DefaultNPlusOneThreshold is used when NPlusOneDetector.Threshold is not set.
+const DefaultNPlusOneThreshold = core.DefaultNPlusOneThreshold
+
+// NPlusOneAnnotation is the message of the annotation added to the request span.
+const NPlusOneAnnotation = core.NPlusOneAnnotation
+
+// NPlusOneDetector flags queries repeated within a request, usually caused by
+// loading associations one record at a time.
+type NPlusOneDetector = core.NPlusOneDetector
+
+// WithNPlusOneDetection seeds a request context for N+1 query detection.
+// The span found in the context is annotated when repeated queries are detected.
+func WithNPlusOneDetection(ctx context.Context) context.Context {
+	return core.WithNPlusOneDetection(ctx)
+}
+
+// WithNPlusOneDetectionSpan seeds a request context for N+1 query detection.
+// The span is annotated when repeated queries are detected.
+func WithNPlusOneDetectionSpan(ctx context.Context, span RequestSpan) context.Context {
+	return core.WithNPlusOneDetectionSpan(ctx, span)
+}
@@ -0,0 +1,124 @@
+package ocgorm
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"go.opencensus.io/trace"
+)
+
+// annotations records the annotations of a request span.
+type annotations struct {
+	messages   []string
+	attributes []map[string]interface{}
+}
+
+func (a *annotations) Annotate(attributes []trace.Attribute, message string) {
+	values := make(map[string]interface{}, len(attributes))
+	for _, attribute := range attributes {
+		values[attribute.Key()] = attribute.Value()
+	}
+
+	a.messages = append(a.messages, message)
+	a.attributes = append(a.attributes, values)
+}
+
+func (a *annotations) AddAttributes(...trace.Attribute) {}
+
+func TestNPlusOneDetector(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int
+		queries   []string
+		detected  map[string]int
+	}{
+		{
+			name:      "below the threshold",
+			threshold: 3,
+			queries:   repeat("SELECT * FROM posts WHERE user_id = 1", 3),
+			detected:  map[string]int{},
+		},
+		{
+			name:      "above the threshold",
+			threshold: 3,
+			queries:   repeat("SELECT * FROM posts WHERE user_id = 1", 4),
+			detected:  map[string]int{"SELECT * FROM posts WHERE user_id = ?": 4},
+		},
+		{
+			name:      "reported once per fingerprint",
+			threshold: 1,
+			queries: append(
+				[]string{"SELECT * FROM posts WHERE user_id = 1", "SELECT * FROM posts WHERE user_id = 2", "SELECT * FROM posts WHERE user_id = 3"},
+				repeat("SELECT * FROM tags WHERE id IN (1,2)", 2)...,
+			),
+			detected: map[string]int{
+				"SELECT * FROM posts WHERE user_id = ?": 2,
+				"SELECT * FROM tags WHERE id IN (?)":    2,
+			},
+		},
+		{
+			name:     "default threshold",
+			queries:  repeat("SELECT * FROM posts WHERE user_id = 1", DefaultNPlusOneThreshold+1),
+			detected: map[string]int{"SELECT * FROM posts WHERE user_id = ?": DefaultNPlusOneThreshold + 1},
+		},
+		{
+			name:     "empty statements",
+			queries:  repeat("", DefaultNPlusOneThreshold+1),
+			detected: map[string]int{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			span := &annotations{}
+			ctx := WithNPlusOneDetectionSpan(context.Background(), span)
+
+			detected := map[string]int{}
+			detector := NPlusOneDetector{
+				Threshold: test.threshold,
+				OnDetect: func(_ context.Context, fingerprint string, count int) {
+					detected[fingerprint] = count
+				},
+			}
+
+			flagged := 0
+			for _, query := range test.queries {
+				if detector.Observe(ctx, query) {
+					flagged++
+				}
+			}
+
+			if !reflect.DeepEqual(detected, test.detected) {
+				t.Errorf("expected the detections %v, got %v", test.detected, detected)
+			}
+
+			if flagged != len(test.detected) || len(span.messages) != len(test.detected) {
+				t.Fatalf("expected %d flagged queries and annotations, got %d and %d", len(test.detected), flagged, len(span.messages))
+			}
+
+			for i, message := range span.messages {
+				fingerprint, _ := span.attributes[i][FingerprintAttribute].(string)
+				if message != NPlusOneAnnotation || span.attributes[i][QueryCountAttribute] != int64(test.detected[fingerprint]) {
+					t.Errorf("unexpected annotation %s %v", message, span.attributes[i])
+				}
+			}
+		})
+	}
+
+	t.Run("context without detection", func(t *testing.T) {
+		if (NPlusOneDetector{Threshold: 1}).Observe(context.Background(), "SELECT 1") {
+			t.Error("expected queries outside of a request not to be flagged")
+		}
+	})
+}
+
+// repeat returns n times the query.
+func repeat(query string, n int) []string {
+	queries := make([]string, n)
+	for i := range queries {
+		queries[i] = query
+	}
+
+	return queries
+}
@@ -0,0 +1,331 @@
+package ocgorm
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// RedactedParameter replaces the value of redacted bind parameters.
+const RedactedParameter = "[REDACTED]"
+
+// ParameterRedaction configures how bind parameters are recorded in spans.
+type ParameterRedaction struct {
+	// Columns redacts parameters bound to a column matching any of the patterns.
+	Columns []*regexp.Regexp
+
+	// MaxLength truncates values longer than MaxLength characters.
+	// Zero means no limit.
+	MaxLength int
+
+	// Types redacts parameters of the listed types (eg. reflect.TypeOf([]byte(nil))).
+	Types []reflect.Type
+}
+
+// FormatParameters formats the bind parameters of a statement as a JSON array of strings.
+// Parameters are matched to columns by the placeholder position in the statement.
+// When Columns is set, parameters whose column cannot be resolved are redacted.
+func (r ParameterRedaction) FormatParameters(sql string, vars []interface{}) string {
+	columns := parameterColumns(sql)
+	values := make([]string, len(vars))
+
+	for i, v := range vars {
+		var column string
+		if i < len(columns) {
+			column = columns[i]
+		}
+
+		values[i] = r.formatParameter(column, v)
+	}
+
+	out, _ := json.Marshal(values)
+
+	return string(out)
+}
+
+func (r ParameterRedaction) formatParameter(column string, v interface{}) string {
+	for _, pattern := range r.Columns {
+		// Fail closed, the parameter may be bound to a redacted column
+		if column == "" || pattern.MatchString(column) {
+			return RedactedParameter
+		}
+	}
+
+	if v != nil {
+		typ := reflect.TypeOf(v)
+		for _, t := range r.Types {
+			if typ == t {
+				return RedactedParameter
+			}
+		}
+	}
+
+	if valuer, ok := v.(driver.Valuer); ok {
+		if value, err := valuer.Value(); err == nil {
+			v = value
+		}
+	}
+
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			v = nil
+		} else {
+			v = rv.Elem().Interface()
+		}
+	}
+
+	var value string
+
+	switch v := v.(type) {
+	case nil:
+		value = "NULL"
+	case []byte:
+		value = string(v)
+	default:
+		value = fmt.Sprint(v)
+	}
+
+	if r.MaxLength > 0 && utf8.RuneCountInString(value) > r.MaxLength {
+		value = string([]rune(value)[:r.MaxLength]) + "..."
+	}
+
+	return value
+}
+
+// SQL keywords that can appear between a column and its placeholder
+var parameterOperators = map[string]bool{
+	"IN": true, "NOT": true, "LIKE": true, "ILIKE": true, "IS": true, "ANY": true, "ALL": true,
+	"ARRAY": true,
+}
+
+// parameterColumns returns the column each placeholder (?, $1, @p1) of the statement is bound to.
+// Placeholders without an obvious column, or ambiguous ones such as a function argument following
+// a column (eg. concat(name, ?)), are reported with an empty name.
+// Placeholders passed to a function are bound to the column the function is compared to
+// (eg. password = crypt(?)).
+func parameterColumns(sql string) []string {
+	var (
+		columns []string
+
+		// Last identifier seen outside of a VALUES list
+		lastIdent string
+
+		// Identifiers of the innermost parenthesized group and of the last closed one
+		groupIdents [][]string
+		lastGroup   []string
+
+		// Whether each open group holds the arguments of a function call
+		groupCalls []bool
+		call       bool
+
+		// Column list of an INSERT statement while inside its VALUES lists
+		insertColumns []string
+		inValues      bool
+		valuesDepth   int
+		valuesIndex   int
+	)
+
+	placeholder := func() {
+		column := lastIdent
+		if n := len(groupIdents); n > 0 && groupCalls[n-1] && len(groupIdents[n-1]) > 0 {
+			column = ""
+		}
+
+		if inValues {
+			column = ""
+			if valuesIndex < len(insertColumns) {
+				column = insertColumns[valuesIndex]
+			}
+		}
+
+		columns = append(columns, column)
+	}
+
+	for i := 0; i < len(sql); {
+		ch := sql[i]
+
+		switch {
+		case ch == '\'':
+			// Skip string literals, with backslash escapes
+			i++
+			for i < len(sql) && sql[i] != '\'' {
+				if sql[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			i++
+
+		case ch == '[' && isSubscript(sql[:i]):
+			// Array subscript or constructor (eg. tags[?], ARRAY[?]), not a quoted identifier
+			i++
+
+		case ch == '`' || ch == '"' || ch == '[':
+			end := ch
+			if ch == '[' {
+				end = ']'
+			}
+
+			start := i + 1
+			i = start
+			for i < len(sql) && sql[i] != end {
+				i++
+			}
+
+			ident := sql[start:min(i, len(sql))]
+			i++
+
+			lastIdent = ident
+			if len(groupIdents) > 0 {
+				groupIdents[len(groupIdents)-1] = append(groupIdents[len(groupIdents)-1], ident)
+			}
+
+		case ch == '?':
+			placeholder()
+			i++
+
+		case i+1 < len(sql) && (ch == '$' && isDigit(sql[i+1]) || ch == '@' && identRuneLen(sql[i+1:]) > 0):
+			i++
+			for n := identRuneLen(sql[i:]); n > 0; n = identRuneLen(sql[i:]) {
+				i += n
+			}
+			placeholder()
+
+		case ch == '(':
+			groupIdents = append(groupIdents, nil)
+			groupCalls = append(groupCalls, call)
+			call = false
+			if inValues {
+				valuesDepth++
+				if valuesDepth == 1 {
+					valuesIndex = 0
+				}
+			}
+			i++
+
+		case ch == ')':
+			if len(groupIdents) > 0 {
+				lastGroup = groupIdents[len(groupIdents)-1]
+				groupIdents = groupIdents[:len(groupIdents)-1]
+				groupCalls = groupCalls[:len(groupCalls)-1]
+			}
+			if inValues {
+				valuesDepth--
+			}
+			i++
+
+		case ch == ',':
+			if inValues && valuesDepth == 1 {
+				valuesIndex++
+			}
+			i++
+
+		case identRuneLen(sql[i:]) > 0:
+			start := i
+			for i < len(sql) {
+				if sql[i] == '.' {
+					i++
+
+					continue
+				}
+
+				n := identRuneLen(sql[i:])
+				if n == 0 {
+					break
+				}
+
+				i += n
+			}
+
+			word := sql[start:i]
+			if idx := strings.LastIndexByte(word, '.'); idx >= 0 {
+				word = word[idx+1:]
+			}
+
+			upper := strings.ToUpper(word)
+
+			next := i
+			for next < len(sql) && unicode.IsSpace(rune(sql[next])) {
+				next++
+			}
+
+			switch {
+			case upper == "VALUES":
+				insertColumns, inValues, valuesDepth = lastGroup, true, 0
+			case inValues && valuesDepth == 0:
+				// The VALUES list is over (eg. ON CONFLICT, RETURNING)
+				inValues = false
+				lastIdent = ""
+			case parameterOperators[upper]:
+			case parameterKeywords[upper]:
+				lastIdent = ""
+			case next < len(sql) && sql[next] == '(':
+				// Function call, its arguments are bound to the column before it
+				call = true
+			case !isDigit(word[0]):
+				lastIdent = word
+				if len(groupIdents) > 0 {
+					groupIdents[len(groupIdents)-1] = append(groupIdents[len(groupIdents)-1], word)
+				}
+			}
+
+		default:
+			i++
+		}
+	}
+
+	return columns
+}
+
+// Keywords that end the association between a column and the following placeholders
+var parameterKeywords = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "AND": true, "OR": true, "SET": true,
+	"LIMIT": true, "OFFSET": true, "BETWEEN": true, "HAVING": true, "ON": true, "JOIN": true,
+	"INSERT": true, "INTO": true, "UPDATE": true, "DELETE": true, "RETURNING": true,
+	"CASE": true, "WHEN": true, "THEN": true, "ELSE": true, "END": true, "AS": true,
+	"ORDER": true, "GROUP": true, "BY": true, "DO": true, "CONFLICT": true,
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+// isSubscript reports whether a '[' following the statement prefix opens an array subscript
+// or constructor (eg. tags[1], ARRAY [?]) rather than a quoted identifier, which only follows
+// a delimiter (eg. SELECT [name], ([name]).
+func isSubscript(prefix string) bool {
+	if prefix == "" {
+		return false
+	}
+
+	if ch := prefix[len(prefix)-1]; ch == ']' || ch == ')' || isIdentByte(ch) {
+		return true
+	}
+
+	prefix = strings.TrimRightFunc(prefix, unicode.IsSpace)
+
+	n := len(prefix) - len("ARRAY")
+
+	return n >= 0 && strings.EqualFold(prefix[n:], "ARRAY") && (n == 0 || !isIdentByte(prefix[n-1]))
+}
+
+// isIdentByte reports whether ch is part of an unquoted identifier, any byte of a multi-byte rune included.
+func isIdentByte(ch byte) bool {
+	return ch == '_' || isDigit(ch) || ch >= utf8.RuneSelf || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z'
+}
+
+// identRuneLen returns the length in bytes of the identifier rune (letter, digit or underscore)
+// starting s, zero if s does not start with one.
+func identRuneLen(s string) int {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+		return size
+	}
+
+	return 0
+}
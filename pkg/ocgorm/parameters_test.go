@@ -0,0 +1,168 @@
+package ocgorm
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestParameterColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		sql     string
+		columns []string
+	}{
+		{
+			name:    "quoted identifiers",
+			sql:     "SELECT * FROM `users` WHERE `users`.`email` = ? AND \"name\" = ? AND [role] <> ?",
+			columns: []string{"email", "name", "role"},
+		},
+		{
+			name:    "insert with multiple values rows",
+			sql:     "INSERT INTO `users` (`name`,`email`) VALUES (?,?),(?,?) RETURNING `id`",
+			columns: []string{"name", "email", "name", "email"},
+		},
+		{
+			name:    "update set",
+			sql:     "UPDATE `users` SET `name`=?,`updated_at`=? WHERE `id` = ?",
+			columns: []string{"name", "updated_at", "id"},
+		},
+		{
+			name:    "in list",
+			sql:     "SELECT * FROM users WHERE id IN (?) AND status NOT IN (?,?)",
+			columns: []string{"id", "status", "status"},
+		},
+		{
+			name:    "numbered placeholders",
+			sql:     "SELECT * FROM users WHERE email = $1 AND name LIKE @p2",
+			columns: []string{"email", "name"},
+		},
+		{
+			name:    "string literals",
+			sql:     "SELECT * FROM users WHERE note = 'a = ?' AND email = ?",
+			columns: []string{"email"},
+		},
+		{
+			name:    "utf-8 identifiers",
+			sql:     "SELECT * FROM utilisateurs WHERE prénom = ? AND 名前 = ?",
+			columns: []string{"prénom", "名前"},
+		},
+		{
+			name:    "placeholder without column",
+			sql:     "SELECT ? FROM users",
+			columns: []string{""},
+		},
+		{
+			name:    "array constructor and subscript",
+			sql:     "SELECT * FROM posts WHERE tags = ARRAY[?] AND scores[1] = ? AND [author] IN (?)",
+			columns: []string{"tags", "scores", "author"},
+		},
+		{
+			name:    "array constructor with space",
+			sql:     "SELECT * FROM posts WHERE tags @> ARRAY [?,?]",
+			columns: []string{"tags", "tags"},
+		},
+		{
+			name:    "backslash escapes",
+			sql:     `SELECT * FROM users WHERE note = 'it\'s = ?' AND password = ?`,
+			columns: []string{"password"},
+		},
+		{
+			name:    "function arguments",
+			sql:     "SELECT * FROM users WHERE password = crypt(?, gen_salt(?)) AND lower(email) = ?",
+			columns: []string{"password", "password", "email"},
+		},
+		{
+			name:    "ambiguous function arguments",
+			sql:     "SELECT * FROM users WHERE login = concat(name, ?)",
+			columns: []string{""},
+		},
+		{
+			name:    "values without column",
+			sql:     "INSERT INTO users VALUES (?,?)",
+			columns: []string{"", ""},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if columns := parameterColumns(test.sql); !reflect.DeepEqual(columns, test.columns) {
+				t.Errorf("expected columns %q, got %q", test.columns, columns)
+			}
+		})
+	}
+}
+
+func TestFormatParameters(t *testing.T) {
+	redaction := ParameterRedaction{Columns: []*regexp.Regexp{regexp.MustCompile(`(?i)password`)}}
+
+	tests := []struct {
+		name   string
+		sql    string
+		vars   []interface{}
+		values string
+	}{
+		{
+			name:   "redacted column",
+			sql:    "SELECT * FROM users WHERE email = ? AND password = ?",
+			vars:   []interface{}{"a@example.com", "secret"},
+			values: `["a@example.com","[REDACTED]"]`,
+		},
+		{
+			name:   "function argument",
+			sql:    "SELECT * FROM users WHERE password = crypt(?) AND id = ?",
+			vars:   []interface{}{"secret", 1},
+			values: `["[REDACTED]","1"]`,
+		},
+		{
+			name:   "backslash escape",
+			sql:    `UPDATE users SET note = 'it\'s', password = ? WHERE id = ?`,
+			vars:   []interface{}{"secret", 1},
+			values: `["[REDACTED]","1"]`,
+		},
+		{
+			name:   "bracket quoting",
+			sql:    "SELECT * FROM [users] WHERE [password] = ? AND [id] IN (?)",
+			vars:   []interface{}{"secret", 1},
+			values: `["[REDACTED]","1"]`,
+		},
+		{
+			name:   "array constructor",
+			sql:    "SELECT * FROM users WHERE tags = ARRAY[?] AND password = ?",
+			vars:   []interface{}{"admin", "secret"},
+			values: `["admin","[REDACTED]"]`,
+		},
+		{
+			name:   "unresolved column",
+			sql:    "SELECT ? FROM users",
+			vars:   []interface{}{"secret"},
+			values: `["[REDACTED]"]`,
+		},
+		{
+			name:   "ambiguous column",
+			sql:    "SELECT * FROM users WHERE hash = concat(password, ?)",
+			vars:   []interface{}{"secret"},
+			values: `["[REDACTED]"]`,
+		},
+		{
+			name:   "more parameters than placeholders",
+			sql:    "INSERT INTO users (name) VALUES (?)",
+			vars:   []interface{}{"alice", "secret"},
+			values: `["alice","[REDACTED]"]`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			values := redaction.FormatParameters(test.sql, test.vars)
+			if values != test.values {
+				t.Errorf("expected %s, got %s", test.values, values)
+			}
+
+			if strings.Contains(values, "secret") {
+				t.Errorf("expected the secret to be redacted, got %s", values)
+			}
+		})
+	}
+}
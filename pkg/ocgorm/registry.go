@@ -0,0 +1,47 @@
+package ocgorm
+
+import (
+	"database/sql"
+
+	"github.com/jinzhu/gorm"
+	"go.opencensus.io/metric/metricproducer"
+
+	"github.com/hashicorp/go-gin-gorm-opencensus/internal/core"
+)
+
+// PoolRegistry is an alternative to RecordStats: the connection pool statistics of the
+// registered databases are read when metrics are exported, so values are never stale and
+// no goroutine is started. Databases are keyed by their DatabaseName tag value.
+//
+// The metrics are named go.sql/db/pool/* (eg. go.sql/db/pool/open), apart from the
+// connection views of DefaultViews, so that both can be exported by the same exporter.
+// The wait rate of RecordStats is not exported, compute it from go.sql/db/pool/wait_count
+// at query time (eg. rate() in Prometheus).
+type PoolRegistry struct {
+	registry *core.PoolRegistry
+}
+
+// NewPoolRegistry returns an empty registry added to the global metric producers.
+// Only the Stats option is used.
+func NewPoolRegistry(opts ...Option) *PoolRegistry {
+	r := &PoolRegistry{registry: core.NewPoolRegistry(opts...)}
+
+	metricproducer.GlobalManager().AddProducer(r.registry)
+
+	return r
+}
+
+// Register reads the statistics of the database under the name, replacing the database of the same name.
+func (r *PoolRegistry) Register(db *gorm.DB, name string) {
+	r.registry.Add(name, func() (*sql.DB, error) { return db.DB(), nil })
+}
+
+// Deregister stops reading the statistics of the database of the name.
+func (r *PoolRegistry) Deregister(name string) {
+	r.registry.Remove(name)
+}
+
+// Close removes the registry from the global metric producers.
+func (r *PoolRegistry) Close() {
+	metricproducer.GlobalManager().DeleteProducer(r.registry)
+}
@@ -0,0 +1,39 @@
+package ocgorm
+
+import (
+	"context"
+
+	"go.opencensus.io/trace"
+
+	"github.com/hashicorp/go-gin-gorm-opencensus/internal/core"
+)
+
+// SQLCommentTags are request scoped values appended to statements as a sqlcommenter comment.
+type SQLCommentTags = core.SQLCommentTags
+
+// WithSQLCommentTags sets the sqlcommenter tags in the context, usually from an HTTP middleware.
+func WithSQLCommentTags(ctx context.Context, tags SQLCommentTags) context.Context {
+	return core.WithSQLCommentTags(ctx, tags)
+}
+
+// SQLCommentTagsFromContext returns the sqlcommenter tags stored in the context.
+func SQLCommentTagsFromContext(ctx context.Context) (SQLCommentTags, bool) {
+	return core.SQLCommentTagsFromContext(ctx)
+}
+
+// SQLComment formats a sqlcommenter comment (https://google.github.io/sqlcommenter/spec/)
+// from the span and the tags found in the context.
+// It returns an empty string if there is nothing to record.
+func SQLComment(ctx context.Context, span *trace.Span, application string) string {
+	if span == nil {
+		return FormatSQLComment(ctx, "", application)
+	}
+
+	return FormatSQLComment(ctx, core.Traceparent(span.SpanContext()), application)
+}
+
+// FormatSQLComment formats a sqlcommenter comment from a W3C traceparent and the tags found in the context.
+// It returns an empty string if there is nothing to record.
+func FormatSQLComment(ctx context.Context, traceparent string, application string) string {
+	return core.FormatSQLComment(ctx, traceparent, application)
+}
@@ -4,40 +4,76 @@
 package ocgorm
 
 import (
-	"context"
-	"strings"
-	"sync"
+	"database/sql"
 	"time"
 
 	"github.com/jinzhu/gorm"
-	"go.opencensus.io/stats"
 	"go.opencensus.io/stats/view"
 	"go.opencensus.io/tag"
+
+	"github.com/hashicorp/go-gin-gorm-opencensus/internal/core"
 )
 
 // Tags applied to measures
 var (
 	// Operation is the type of query (SELECT, INSERT, UPDATE, DELETE)
-	Operation, _ = tag.NewKey("sql.operation")
+	Operation = core.Operation
 
 	// Table name of the target database table
-	Table, _ = tag.NewKey("sql.table")
+	Table = core.Table
+
+	// Model is the Go type name of the model, only recorded with the ModelTag option
+	Model = core.Model
 
 	// DatabaseName is the name of the target database
-	DatabaseName, _ = tag.NewKey("database_name")
+	DatabaseName = core.DatabaseName
+
+	// LimitedTag is the key of the tag values replaced by the CardinalityLimiter
+	LimitedTag = core.LimitedTag
 )
 
 // Measures
 var (
-	MeasureQueryCount        = stats.Int64("go.sql/client/calls", "Number of queries started", stats.UnitDimensionless)
-	MeasureLatencyMs         = stats.Float64("go.sql/client/latency", "The latency of calls in milliseconds", stats.UnitMilliseconds)
-	MeasureOpenConnections   = stats.Int64("go.sql/connections/open", "Count of open connections in the pool", stats.UnitDimensionless)
-	MeasureIdleConnections   = stats.Int64("go.sql/connections/idle", "Count of idle connections in the pool", stats.UnitDimensionless)
-	MeasureActiveConnections = stats.Int64("go.sql/connections/active", "Count of active connections in the pool", stats.UnitDimensionless)
-	MeasureWaitCount         = stats.Int64("go.sql/connections/wait_count", "The total number of connections waited for", stats.UnitDimensionless)
-	MeasureWaitDuration      = stats.Float64("go.sql/connections/wait_duration", "The total time blocked waiting for a new connection", stats.UnitMilliseconds)
-	MeasureIdleClosed        = stats.Int64("go.sql/connections/idle_closed", "The total number of connections closed due to SetMaxIdleConns", stats.UnitDimensionless)
-	MeasureLifetimeClosed    = stats.Int64("go.sql/connections/lifetime_closed", "The total number of connections closed due to SetConnMaxLifetime", stats.UnitDimensionless)
+	MeasureQueryCount        = core.MeasureQueryCount
+	MeasureLatencyMs         = core.MeasureLatencyMs
+	MeasureOpenConnections   = core.MeasureOpenConnections
+	MeasureIdleConnections   = core.MeasureIdleConnections
+	MeasureActiveConnections = core.MeasureActiveConnections
+	MeasureWaitCount         = core.MeasureWaitCount
+	MeasureWaitDuration      = core.MeasureWaitDuration
+	MeasureIdleClosed        = core.MeasureIdleClosed
+	MeasureLifetimeClosed    = core.MeasureLifetimeClosed
+
+	// sql.DBStats.MaxOpenConnections and MaxIdleTimeClosed
+	MeasureMaxOpenConnections = core.MeasureMaxOpenConnections
+	MeasureIdleTimeClosed     = core.MeasureIdleTimeClosed
+
+	// MeasurePoolSaturation is the ratio of connections in use to MaxOpenConnections, zero if unlimited
+	MeasurePoolSaturation = core.MeasurePoolSaturation
+
+	// MeasureWaitRate is computed from the WaitCount delta between two RecordStats intervals.
+	// PoolRegistry does not export it, compute it from go.sql/db/pool/wait_count at query time (eg. rate() in Prometheus)
+	MeasureWaitRate = core.MeasureWaitRate
+
+	// MeasureAverageWait is the WaitDuration delta divided by the WaitCount delta between two RecordStats intervals
+	MeasureAverageWait = core.MeasureAverageWait
+
+	// Increase of the sql.DBStats totals since the previous RecordStats interval,
+	// aggregated into cumulative metrics by the connection views named *_total
+	MeasureWaits                     = core.MeasureWaits
+	MeasureWaitTime                  = core.MeasureWaitTime
+	MeasureIdleClosedConnections     = core.MeasureIdleClosedConnections
+	MeasureLifetimeClosedConnections = core.MeasureLifetimeClosedConnections
+	MeasureIdleTimeClosedConnections = core.MeasureIdleTimeClosedConnections
+
+	// MeasureTruncatedAttributes counts span attributes truncated by MaxStatementLength and MaxAttributesLength
+	MeasureTruncatedAttributes = core.MeasureTruncatedAttributes
+
+	// MeasureNPlusOne counts queries flagged by the NPlusOneDetector
+	MeasureNPlusOne = core.MeasureNPlusOne
+
+	// MeasureDroppedTagValues counts tag values replaced by the CardinalityLimiter
+	MeasureDroppedTagValues = core.MeasureDroppedTagValues
 )
 
 // Default distributions used by views in this package
@@ -93,6 +129,30 @@ var (
 		TagKeys:     []tag.Key{Operation, Table},
 	}
 
+	SQLClientTruncatedAttributesView = &view.View{
+		Name:        "go.sql/client/truncated_attributes",
+		Description: "The number of span attribute values truncated",
+		Measure:     MeasureTruncatedAttributes,
+		Aggregation: view.Sum(),
+		TagKeys:     []tag.Key{Operation, Table},
+	}
+
+	SQLClientNPlusOneView = &view.View{
+		Name:        "go.sql/client/n_plus_one",
+		Description: "The number of repeated queries detected within a request",
+		Measure:     MeasureNPlusOne,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{Operation, Table},
+	}
+
+	SQLClientDroppedTagValuesView = &view.View{
+		Name:        "go.sql/client/dropped_tag_values",
+		Description: "The number of tag values replaced by the cardinality limiter",
+		Measure:     MeasureDroppedTagValues,
+		Aggregation: view.Sum(),
+		TagKeys:     []tag.Key{LimitedTag},
+	}
+
 	SQLClientOpenConnectionsView = &view.View{
 		Name:        "go.sql/db/connections/open",
 		Description: "The number of open connections",
@@ -117,6 +177,7 @@ var (
 		TagKeys:     []tag.Key{DatabaseName},
 	}
 
+	// Deprecated: SQLClientWaitCountView exports the total as a gauge, use SQLClientWaitCountTotalView.
 	SQLClientWaitCountView = &view.View{
 		Name:        "go.sql/db/connections/wait_count",
 		Description: "The total number of connections waited for",
@@ -125,6 +186,15 @@ var (
 		TagKeys:     []tag.Key{DatabaseName},
 	}
 
+	SQLClientWaitCountTotalView = &view.View{
+		Name:        "go.sql/db/connections/wait_count_total",
+		Description: "The total number of connections waited for",
+		Measure:     MeasureWaits,
+		Aggregation: view.Sum(),
+		TagKeys:     []tag.Key{DatabaseName},
+	}
+
+	// Deprecated: SQLClientWaitDurationView exports the total as a gauge, use SQLClientWaitDurationTotalView.
 	SQLClientWaitDurationView = &view.View{
 		Name:        "go.sql/db/connections/wait_duration",
 		Description: "The total time blocked waiting for a new connection",
@@ -133,6 +203,15 @@ var (
 		TagKeys:     []tag.Key{DatabaseName},
 	}
 
+	SQLClientWaitDurationTotalView = &view.View{
+		Name:        "go.sql/db/connections/wait_duration_total",
+		Description: "The total time blocked waiting for a new connection",
+		Measure:     MeasureWaitTime,
+		Aggregation: view.Sum(),
+		TagKeys:     []tag.Key{DatabaseName},
+	}
+
+	// Deprecated: SQLClientIdleClosedView exports the total as a gauge, use SQLClientIdleClosedTotalView.
 	SQLClientIdleClosedView = &view.View{
 		Name:        "go.sql/db/connections/idle_closed_count",
 		Description: "The total number of connections closed due to SetMaxIdleConns",
@@ -141,6 +220,15 @@ var (
 		TagKeys:     []tag.Key{DatabaseName},
 	}
 
+	SQLClientIdleClosedTotalView = &view.View{
+		Name:        "go.sql/db/connections/idle_closed_total",
+		Description: "The total number of connections closed due to SetMaxIdleConns",
+		Measure:     MeasureIdleClosedConnections,
+		Aggregation: view.Sum(),
+		TagKeys:     []tag.Key{DatabaseName},
+	}
+
+	// Deprecated: SQLClientLifetimeClosedView exports the total as a gauge, use SQLClientLifetimeClosedTotalView.
 	SQLClientLifetimeClosedView = &view.View{
 		Name:        "go.sql/db/connections/lifetime_closed_count",
 		Description: "The total number of connections closed due to SetConnMaxLifetime",
@@ -149,11 +237,104 @@ var (
 		TagKeys:     []tag.Key{DatabaseName},
 	}
 
+	SQLClientLifetimeClosedTotalView = &view.View{
+		Name:        "go.sql/db/connections/lifetime_closed_total",
+		Description: "The total number of connections closed due to SetConnMaxLifetime",
+		Measure:     MeasureLifetimeClosedConnections,
+		Aggregation: view.Sum(),
+		TagKeys:     []tag.Key{DatabaseName},
+	}
+
+	SQLClientMaxOpenConnectionsView = &view.View{
+		Name:        "go.sql/db/connections/max_open",
+		Description: "The maximum number of open connections",
+		Measure:     MeasureMaxOpenConnections,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{DatabaseName},
+	}
+
+	// Deprecated: SQLClientIdleTimeClosedView exports the total as a gauge, use SQLClientIdleTimeClosedTotalView.
+	SQLClientIdleTimeClosedView = &view.View{
+		Name:        "go.sql/db/connections/idle_time_closed_count",
+		Description: "The total number of connections closed due to SetConnMaxIdleTime",
+		Measure:     MeasureIdleTimeClosed,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{DatabaseName},
+	}
+
+	SQLClientIdleTimeClosedTotalView = &view.View{
+		Name:        "go.sql/db/connections/idle_time_closed_total",
+		Description: "The total number of connections closed due to SetConnMaxIdleTime",
+		Measure:     MeasureIdleTimeClosedConnections,
+		Aggregation: view.Sum(),
+		TagKeys:     []tag.Key{DatabaseName},
+	}
+
+	SQLClientPoolSaturationView = &view.View{
+		Name:        "go.sql/db/connections/saturation",
+		Description: "The ratio of connections in use to the maximum number of open connections",
+		Measure:     MeasurePoolSaturation,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{DatabaseName},
+	}
+
+	SQLClientWaitRateView = &view.View{
+		Name:        "go.sql/db/connections/wait_rate",
+		Description: "The number of connections waited for per second",
+		Measure:     MeasureWaitRate,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{DatabaseName},
+	}
+
+	SQLClientAverageWaitView = &view.View{
+		Name:        "go.sql/db/connections/average_wait",
+		Description: "The average time blocked waiting for a new connection in milliseconds",
+		Measure:     MeasureAverageWait,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{DatabaseName},
+	}
+
+	SQLClientModelLatencyView = &view.View{
+		Name:        "go.sql/client/model_latency",
+		Description: "The distribution of latencies of various calls by model in milliseconds",
+		Measure:     MeasureLatencyMs,
+		Aggregation: DefaultMillisecondsDistribution,
+		TagKeys:     []tag.Key{Operation, Table, Model},
+	}
+
+	SQLClientModelCallsView = &view.View{
+		Name:        "go.sql/client/model_calls",
+		Description: "The number of various calls of methods by model",
+		Measure:     MeasureQueryCount,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{Operation, Table, Model},
+	}
+
+	// ModelViews aggregate stats by model, register them when using the ModelTag option.
+	ModelViews = []*view.View{SQLClientModelLatencyView, SQLClientModelCallsView}
+
 	DefaultViews = []*view.View{
 		SQLClientCallsView, SQLClientLatencyView, SQLClientOpenConnectionsView,
 		SQLClientIdleConnectionsView, SQLClientActiveConnectionsView,
+		SQLClientWaitCountTotalView, SQLClientWaitDurationTotalView,
+		SQLClientIdleClosedTotalView, SQLClientLifetimeClosedTotalView,
+		SQLClientMaxOpenConnectionsView, SQLClientIdleTimeClosedTotalView,
+		SQLClientPoolSaturationView, SQLClientWaitRateView, SQLClientAverageWaitView,
+		SQLClientTruncatedAttributesView, SQLClientNPlusOneView,
+		SQLClientDroppedTagValuesView,
+	}
+
+	// LegacyPoolViews are the deprecated gauges of the connection pool totals, replaced in DefaultViews
+	// by the cumulative views of PoolViews. See StatsConfig.LegacyPoolViews.
+	LegacyPoolViews = []*view.View{
 		SQLClientWaitCountView, SQLClientWaitDurationView,
-		SQLClientIdleClosedView, SQLClientLifetimeClosedView,
+		SQLClientIdleClosedView, SQLClientLifetimeClosedView, SQLClientIdleTimeClosedView,
+	}
+
+	// PoolViews are the cumulative views of the connection pool totals, in the order of LegacyPoolViews.
+	PoolViews = []*view.View{
+		SQLClientWaitCountTotalView, SQLClientWaitDurationTotalView,
+		SQLClientIdleClosedTotalView, SQLClientLifetimeClosedTotalView, SQLClientIdleTimeClosedTotalView,
 	}
 )
 
@@ -167,47 +348,14 @@ func RegisterAllViews() {
 // RecordStats records database statistics for provided sql.DB at the provided
 // interval. You should defer execution of this function after you establish
 // connection to the database `if err == nil { ocgorm.RecordStats(db, 5*time.Second); }
-func RecordStats(db *gorm.DB, interval time.Duration, name string) (fnStop func()) {
-	var (
-		closeOnce sync.Once
-		ctx       = context.Background()
-		ticker    = time.NewTicker(interval)
-		done      = make(chan struct{})
-	)
-
-	go func() {
-		for {
-			select {
-			case <-ticker.C:
-				dbStats := db.DB().Stats()
-
-				if dbStats.OpenConnections == 0 { // We cleanup the ticker in the event that the database is unavailable
-					if err := db.DB().Ping(); err != nil && strings.Contains(err.Error(), "database is closed") {
-						ticker.Stop()
-						return
-					}
-				}
-
-				stats.RecordWithTags(ctx,
-					[]tag.Mutator{tag.Upsert(DatabaseName, name)},
-					MeasureOpenConnections.M(int64(dbStats.OpenConnections)),
-					MeasureIdleConnections.M(int64(dbStats.Idle)),
-					MeasureActiveConnections.M(int64(dbStats.InUse)),
-					MeasureWaitCount.M(dbStats.WaitCount),
-					MeasureWaitDuration.M(float64(dbStats.WaitDuration.Nanoseconds())/1e6),
-					MeasureIdleClosed.M(dbStats.MaxIdleClosed),
-					MeasureLifetimeClosed.M(dbStats.MaxLifetimeClosed),
-				)
-			case <-done:
-				ticker.Stop()
-				return
-			}
-		}
-	}()
-
-	return func() {
-		closeOnce.Do(func() {
-			close(done)
-		})
-	}
+// Only the Stats option is used.
+func RecordStats(db *gorm.DB, interval time.Duration, name string, opts ...Option) (fnStop func()) {
+	return core.RecordStats(func() (*sql.DB, error) { return db.DB(), nil }, interval, name, opts...)
+}
+
+// WaitStats returns the number of connections waited for per second and the average time waited
+// for a connection between two sql.DBStats snapshots taken interval apart, as recorded by RecordStats
+// with MeasureWaitRate and MeasureAverageWait.
+func WaitStats(current, previous sql.DBStats, interval time.Duration) (rate float64, average time.Duration) {
+	return core.WaitStats(current, previous, interval)
 }
@@ -0,0 +1,51 @@
+package ocgorm
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestWaitStats(t *testing.T) {
+	snapshots := []struct {
+		stats   sql.DBStats
+		rate    float64
+		average time.Duration
+	}{
+		{
+			stats: sql.DBStats{},
+		},
+		{
+			stats:   sql.DBStats{WaitCount: 4, WaitDuration: 200 * time.Millisecond},
+			rate:    0.4,
+			average: 50 * time.Millisecond,
+		},
+		{
+			// No wait during the interval
+			stats: sql.DBStats{WaitCount: 4, WaitDuration: 200 * time.Millisecond},
+		},
+		{
+			// A single slow wait raises the average, not the rate
+			stats:   sql.DBStats{WaitCount: 5, WaitDuration: 1200 * time.Millisecond},
+			rate:    0.1,
+			average: time.Second,
+		},
+		{
+			// The database was reopened, the totals were reset
+			stats:   sql.DBStats{WaitCount: 2, WaitDuration: 30 * time.Millisecond},
+			rate:    0.2,
+			average: 15 * time.Millisecond,
+		},
+	}
+
+	const interval = 10 * time.Second
+
+	for i := 1; i < len(snapshots); i++ {
+		rate, average := WaitStats(snapshots[i].stats, snapshots[i-1].stats, interval)
+
+		if rate != snapshots[i].rate || average != snapshots[i].average {
+			t.Errorf("snapshot %d: expected rate %v and average %s, got %v and %s",
+				i, snapshots[i].rate, snapshots[i].average, rate, average)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package ocgorm
+
+import (
+	"context"
+
+	"github.com/hashicorp/go-gin-gorm-opencensus/internal/core"
+)
+
+// ErrQueryBudgetExceeded is returned for queries exceeding the request budget when QueryBudget.Fail is set.
+var ErrQueryBudgetExceeded = core.ErrQueryBudgetExceeded
+
+// QueryBudgetExceededAnnotation is the message of the annotation added to the request span.
+const QueryBudgetExceededAnnotation = core.QueryBudgetExceededAnnotation
+
+// QueryBudget limits the number of queries run within a request.
+type QueryBudget = core.QueryBudget
+
+// RequestSpan records the events and totals of a request, an OpenCensus *trace.Span
+// or an adapter of another tracing API.
+type RequestSpan = core.RequestSpan
+
+// QuerySummary accumulates the queries run within a request
+// and records the totals on the request span.
+type QuerySummary = core.QuerySummary
+
+// WithQuerySummary seeds a request context with a query summary.
+// The summary is recorded on the span found in the context.
+func WithQuerySummary(ctx context.Context, budget QueryBudget) context.Context {
+	return core.WithQuerySummary(ctx, budget)
+}
+
+// WithQuerySummarySpan seeds a request context with a query summary recorded on the span.
+func WithQuerySummarySpan(ctx context.Context, budget QueryBudget, span RequestSpan) context.Context {
+	return core.WithQuerySummarySpan(ctx, budget, span)
+}
+
+// QuerySummaryFromContext returns the query summary of the request, if any.
+func QuerySummaryFromContext(ctx context.Context) (*QuerySummary, bool) {
+	return core.QuerySummaryFromContext(ctx)
+}
@@ -0,0 +1,94 @@
+package ocgorm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestQueryBudget(t *testing.T) {
+	tests := []struct {
+		name     string
+		budget   QueryBudget
+		queries  int
+		exceeded []int
+		failed   int
+	}{
+		{
+			name:    "no limit",
+			budget:  QueryBudget{},
+			queries: 5,
+		},
+		{
+			name:    "within the budget",
+			budget:  QueryBudget{MaxQueries: 5},
+			queries: 5,
+		},
+		{
+			name:     "exceeded once",
+			budget:   QueryBudget{MaxQueries: 2},
+			queries:  5,
+			exceeded: []int{3},
+		},
+		{
+			name:     "failing budget",
+			budget:   QueryBudget{MaxQueries: 2, Fail: true},
+			queries:  5,
+			exceeded: []int{3},
+			failed:   3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var exceeded []int
+
+			budget := test.budget
+			budget.OnExceeded = func(_ context.Context, queries int) {
+				exceeded = append(exceeded, queries)
+			}
+
+			span := &annotations{}
+			ctx := WithQuerySummarySpan(context.Background(), budget, span)
+
+			summary, ok := QuerySummaryFromContext(ctx)
+			if !ok {
+				t.Fatal("expected a query summary in the context")
+			}
+
+			failed := 0
+			for i := 0; i < test.queries; i++ {
+				if err := summary.Start(ctx); err != nil {
+					if !errors.Is(err, ErrQueryBudgetExceeded) {
+						t.Fatalf("expected ErrQueryBudgetExceeded, got %v", err)
+					}
+
+					failed++
+				}
+			}
+
+			if !reflect.DeepEqual(exceeded, test.exceeded) {
+				t.Errorf("expected OnExceeded to be called with %v, got %v", test.exceeded, exceeded)
+			}
+
+			if len(span.messages) != len(test.exceeded) {
+				t.Errorf("expected %d annotations, got %v", len(test.exceeded), span.messages)
+			}
+
+			for _, message := range span.messages {
+				if message != QueryBudgetExceededAnnotation {
+					t.Errorf("unexpected annotation %s", message)
+				}
+			}
+
+			if failed != test.failed {
+				t.Errorf("expected %d failed queries, got %d", test.failed, failed)
+			}
+
+			if summary.Queries() != test.queries {
+				t.Errorf("expected %d queries, got %d", test.queries, summary.Queries())
+			}
+		})
+	}
+}
@@ -1,11 +1,36 @@
 package ocgorm
 
+import (
+	"github.com/hashicorp/go-gin-gorm-opencensus/internal/core"
+)
+
 // Attributes recorded on the span for the queries.
 const (
 	// Datadog expects the query text here to enable aggregations of queries
-	// Must be used in tandem with a service.name and span.type attribute
+	// Must be used in tandem with a service.name and span.type attribute, see DatadogConventions
 	// Our fork uses this instead of gorm.query
-	ResourceNameAttribute = "resource.name"
+	ResourceNameAttribute = core.ResourceNameAttribute
+
+	TableAttribute = core.TableAttribute
+
+	// ModelAttribute is the Go type name of the model
+	ModelAttribute = core.ModelAttribute
+
+	// ParametersAttribute holds the bind parameters of the query
+	ParametersAttribute = core.ParametersAttribute
+
+	// FingerprintAttribute is the normalized query, see Fingerprint
+	FingerprintAttribute = core.FingerprintAttribute
+
+	// QueryCountAttribute is the number of queries run
+	QueryCountAttribute = core.QueryCountAttribute
+
+	// Totals of the queries run within a request, see QuerySummary
+	TotalTimeAttribute = core.TotalTimeAttribute
+	RowsAttribute      = core.RowsAttribute
 
-	TableAttribute = "gorm.table"
+	// Location of the code issuing the query
+	CodeFunctionAttribute = core.CodeFunctionAttribute
+	CodeFilepathAttribute = core.CodeFilepathAttribute
+	CodeLinenoAttribute   = core.CodeLinenoAttribute
 )
@@ -0,0 +1,136 @@
+package ocgorm
+
+import (
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/hashicorp/go-gin-gorm-opencensus/internal/core"
+)
+
+// StatsConfig builds the views of the package with custom names, tags and buckets.
+//
+// Use the Stats option so that the callbacks and RecordStats record the measures
+// with the same tag keys as the views.
+type StatsConfig struct {
+	// Names of the views by default view name (eg. "go.sql/client/latency": "db/latency").
+	// Views missing from the map keep their default name.
+	Names map[string]string
+
+	// Tag keys replacing Operation, Table, Model and DatabaseName, the default keys if zero.
+	Operation    tag.Key
+	Table        tag.Key
+	Model        tag.Key
+	DatabaseName tag.Key
+
+	// ExtraTags are added to SQLClientLatencyView and SQLClientCallsView, their values are taken
+	// from the context of the queries (eg. tenant, endpoint or the route tagged by ochttp).
+	// The Stats option records them as ContextTags.
+	ExtraTags []tag.Key
+
+	// LatencyBuckets are the bucket boundaries of the latency distributions in milliseconds.
+	// DefaultMillisecondsDistribution is used if empty.
+	LatencyBuckets []float64
+
+	// LegacyPoolViews replaces the cumulative views of the connection pool totals (PoolViews)
+	// with the deprecated gauges (LegacyPoolViews) in DefaultViews.
+	//
+	// To migrate, build the dashboards and alerts on the rate of the *_total metrics
+	// instead of the deltas of the gauges, then unset LegacyPoolViews.
+	LegacyPoolViews bool
+}
+
+// Stats records the measures with the tag keys of the configuration.
+func Stats(s StatsConfig) Option {
+	return OptionFunc(func(c *core.Config) {
+		c.OperationKey = s.Operation
+		c.TableKey = s.Table
+		c.ModelKey = s.Model
+		c.DatabaseNameKey = s.DatabaseName
+		c.ContextTags = append(c.ContextTags, s.ExtraTags...)
+	})
+}
+
+// ContextTags records the values of tag keys set upstream in the context of the queries
+// (eg. tenant, endpoint or service) with the latency and calls measures, bounded by CardinalityLimit.
+//
+// OpenCensus views only aggregate the keys they list: register the views built with the same keys
+// as StatsConfig.ExtraTags (eg. StatsConfig{ExtraTags: keys}.DefaultViews()).
+func ContextTags(keys ...tag.Key) Option {
+	return OptionFunc(func(c *core.Config) {
+		c.ContextTags = append(c.ContextTags, keys...)
+	})
+}
+
+// DefaultViews returns the views of DefaultViews built with the configuration.
+func (s StatsConfig) DefaultViews() []*view.View {
+	views := s.views(DefaultViews)
+
+	if s.LegacyPoolViews {
+		for i, v := range DefaultViews {
+			for j, pool := range PoolViews {
+				if v == pool {
+					views[i] = s.view(LegacyPoolViews[j])
+				}
+			}
+		}
+	}
+
+	return views
+}
+
+// ModelViews returns the views of ModelViews built with the configuration.
+func (s StatsConfig) ModelViews() []*view.View {
+	return s.views(ModelViews)
+}
+
+func (s StatsConfig) views(defaults []*view.View) []*view.View {
+	views := make([]*view.View, len(defaults))
+
+	for i, v := range defaults {
+		views[i] = s.view(v)
+	}
+
+	return views
+}
+
+// Measures recorded with the context tags
+var contextTagMeasures = map[stats.Measure]bool{
+	MeasureLatencyMs:  true,
+	MeasureQueryCount: true,
+}
+
+func (s StatsConfig) view(v *view.View) *view.View {
+	built := *v
+
+	if name, ok := s.Names[v.Name]; ok {
+		built.Name = name
+	}
+
+	keys := map[tag.Key]tag.Key{
+		Operation:    s.Operation,
+		Table:        s.Table,
+		Model:        s.Model,
+		DatabaseName: s.DatabaseName,
+	}
+
+	built.TagKeys = make([]tag.Key, 0, len(v.TagKeys)+len(s.ExtraTags))
+
+	for _, key := range v.TagKeys {
+		if custom := keys[key]; custom.Name() != "" {
+			key = custom
+		}
+
+		built.TagKeys = append(built.TagKeys, key)
+	}
+
+	if contextTagMeasures[v.Measure] {
+		built.TagKeys = append(built.TagKeys, s.ExtraTags...)
+	}
+
+	if v.Aggregation == DefaultMillisecondsDistribution && len(s.LatencyBuckets) > 0 {
+		built.Aggregation = view.Distribution(s.LatencyBuckets...)
+	}
+
+	return &built
+}
@@ -0,0 +1,91 @@
+package ocgorm
+
+import (
+	"reflect"
+	"testing"
+
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+func TestStatsConfigExtraTags(t *testing.T) {
+	tenant := tag.MustNewKey("tenant")
+	s := StatsConfig{ExtraTags: []tag.Key{tenant}}
+
+	tests := []struct {
+		name string
+		view *view.View
+		keys []tag.Key
+	}{
+		{
+			name: "latency",
+			view: SQLClientLatencyView,
+			keys: []tag.Key{Operation, Table, tenant},
+		},
+		{
+			name: "calls",
+			view: SQLClientCallsView,
+			keys: []tag.Key{Operation, Table, tenant},
+		},
+		{
+			name: "truncated attributes",
+			view: SQLClientTruncatedAttributesView,
+			keys: []tag.Key{Operation, Table},
+		},
+		{
+			name: "n+1",
+			view: SQLClientNPlusOneView,
+			keys: []tag.Key{Operation, Table},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if keys := s.view(test.view).TagKeys; !reflect.DeepEqual(keys, test.keys) {
+				t.Errorf("expected tag keys %v, got %v", test.keys, keys)
+			}
+		})
+	}
+}
+
+func TestStatsConfigLegacyPoolViews(t *testing.T) {
+	tests := []struct {
+		name     string
+		legacy   bool
+		included []*view.View
+		excluded []*view.View
+	}{
+		{
+			name:     "cumulative views",
+			included: PoolViews,
+			excluded: LegacyPoolViews,
+		},
+		{
+			name:     "legacy views",
+			legacy:   true,
+			included: LegacyPoolViews,
+			excluded: PoolViews,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			names := map[string]bool{}
+			for _, v := range (StatsConfig{LegacyPoolViews: test.legacy}).DefaultViews() {
+				names[v.Name] = true
+			}
+
+			for _, v := range test.included {
+				if !names[v.Name] {
+					t.Errorf("expected %s in the default views", v.Name)
+				}
+			}
+
+			for _, v := range test.excluded {
+				if names[v.Name] {
+					t.Errorf("expected %s not to be in the default views", v.Name)
+				}
+			}
+		})
+	}
+}
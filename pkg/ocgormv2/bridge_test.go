@@ -0,0 +1,178 @@
+package ocgormv2_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	octrace "go.opencensus.io/trace"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/gorm"
+
+	"github.com/hashicorp/go-gin-gorm-opencensus/pkg/ocgormv2"
+	"github.com/hashicorp/go-gin-gorm-opencensus/pkg/otelgin"
+)
+
+type bridgeModel struct {
+	ID int
+}
+
+// restoreTracer restores the OpenCensus tracer replaced by the bridge at the end of the test.
+func restoreTracer(t *testing.T) {
+	tracer := octrace.DefaultTracer
+	t.Cleanup(func() { octrace.DefaultTracer = tracer })
+}
+
+func TestOpenTelemetryBridge(t *testing.T) {
+	restoreTracer(t)
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.AutoMigrate(&bridgeModel{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ocgormv2.RegisterCallbacks(db, ocgormv2.OpenTelemetryBridge(tp)); err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.Use(otelgin.Middleware(otelgin.TracerProvider(tp)))
+	engine.GET("/models", func(c *gin.Context) {
+		var models []bridgeModel
+		if err := db.WithContext(c.Request.Context()).Find(&models).Error; err != nil {
+			_ = c.AbortWithError(http.StatusInternalServerError, err)
+
+			return
+		}
+
+		c.JSON(http.StatusOK, models)
+	})
+
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/models", nil))
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected a gorm and a gin span, got %d spans", len(spans))
+	}
+
+	query, request := spans[0], spans[1]
+
+	if query.Name != "gorm:query" {
+		t.Errorf("expected the gorm span to end first, got %q", query.Name)
+	}
+
+	if query.SpanContext.TraceID() != request.SpanContext.TraceID() {
+		t.Errorf("expected one trace, got %s and %s", query.SpanContext.TraceID(), request.SpanContext.TraceID())
+	}
+
+	if query.Parent.SpanID() != request.SpanContext.SpanID() {
+		t.Errorf("expected the gorm span to be a child of %s, got %s", request.SpanContext.SpanID(), query.Parent.SpanID())
+	}
+}
+
+func TestOpenTelemetryBridgeOpenCensusParent(t *testing.T) {
+	restoreTracer(t)
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.AutoMigrate(&bridgeModel{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ocgormv2.RegisterCallbacks(db, ocgormv2.OpenTelemetryBridge(tp)); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, span := octrace.StartSpan(context.Background(), "job")
+	ctx, child := tp.Tracer("test").Start(ctx, "step")
+
+	var models []bridgeModel
+	if err := db.WithContext(ctx).Find(&models).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	child.End()
+	span.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(spans))
+	}
+
+	query, step, job := spans[0], spans[1], spans[2]
+
+	if step.Parent.SpanID() != job.SpanContext.SpanID() {
+		t.Errorf("expected the OpenTelemetry span to be a child of the OpenCensus span")
+	}
+
+	if query.Parent.SpanID() != step.SpanContext.SpanID() {
+		t.Errorf("expected the gorm span to be a child of the OpenTelemetry span")
+	}
+
+	for _, s := range spans {
+		if s.SpanContext.TraceID() != job.SpanContext.TraceID() {
+			t.Errorf("expected one trace, span %q is in %s", s.Name, s.SpanContext.TraceID())
+		}
+	}
+}
+
+func TestOpenTelemetryBridgeStatus(t *testing.T) {
+	restoreTracer(t)
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ocgormv2.RegisterCallbacks(db, ocgormv2.OpenTelemetryBridge(tp)); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "job")
+
+	// The table is not migrated, the query fails
+	var models []bridgeModel
+	if err := db.WithContext(ctx).Find(&models).Error; err == nil {
+		t.Fatal("expected the query to fail")
+	}
+
+	span.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+
+	query := spans[0]
+
+	if query.Status.Code != codes.Error {
+		t.Errorf("expected the gorm span status to be %s, got %s", codes.Error, query.Status.Code)
+	}
+
+	if query.Status.Description == "" {
+		t.Error("expected the gorm span status to describe the error")
+	}
+}
@@ -0,0 +1,289 @@
+package ocgormv2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.opencensus.io/trace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/hashicorp/go-gin-gorm-opencensus/internal/caller"
+	"github.com/hashicorp/go-gin-gorm-opencensus/internal/core"
+	"github.com/hashicorp/go-gin-gorm-opencensus/internal/ocotel"
+	"github.com/hashicorp/go-gin-gorm-opencensus/pkg/ocgorm"
+	"github.com/hashicorp/go-gin-gorm-opencensus/pkg/ocslog"
+)
+
+// Messages of the query logs and of the annotations mirroring them on spans
+const (
+	QueryLog             = "gorm: query"
+	SlowQueryAnnotation  = "gorm: slow query"
+	QueryErrorAnnotation = "gorm: query error"
+)
+
+// Attributes of the query logs and annotations
+const (
+	statementAttribute    = "db.statement"
+	durationAttribute     = "db.duration_ms"
+	rowsAffectedAttribute = "db.rows_affected"
+	errorAttribute        = "error"
+)
+
+// LoggerConfig configures the logger.
+type LoggerConfig struct {
+	// LogLevel of the gorm messages and queries, logger.Warn if zero.
+	// Only used by NewSlogLogger, the wrapped logger of NewLogger keeps its own level.
+	LogLevel logger.LogLevel
+
+	// SlowThreshold mirrors queries slower than the threshold as span annotations,
+	// and logs them as warnings with NewSlogLogger. Zero disables slow query annotations.
+	SlowThreshold time.Duration
+
+	// IgnoreRecordNotFoundError does not log queries failing with gorm.ErrRecordNotFound.
+	// Only used by NewSlogLogger.
+	IgnoreRecordNotFoundError bool
+
+	// Errors mirrors failed queries as span annotations.
+	Errors bool
+}
+
+// annotation returns the message of the annotation mirroring a query, empty if none.
+func (c LoggerConfig) annotation(err error, elapsed time.Duration) string {
+	switch {
+	case err != nil && c.Errors && !errors.Is(err, gorm.ErrRecordNotFound):
+		return QueryErrorAnnotation
+	case c.SlowThreshold > 0 && elapsed > c.SlowThreshold:
+		return SlowQueryAnnotation
+	default:
+		return ""
+	}
+}
+
+type tracingLogger struct {
+	logger.Interface
+
+	config LoggerConfig
+}
+
+// NewLogger wraps a gorm logger to add the trace_id and span_id of the span
+// found in the context to each log.
+// Note that gorm's default logger reports this wrapper as the caller location, see NewSlogLogger.
+//
+// Gorm logs a query after the instrumentation span has ended,
+// annotations are added to its parent span instead.
+func NewLogger(l logger.Interface, config LoggerConfig) logger.Interface {
+	return &tracingLogger{
+		Interface: l,
+		config:    config,
+	}
+}
+
+func (l *tracingLogger) LogMode(level logger.LogLevel) logger.Interface {
+	return &tracingLogger{
+		Interface: l.Interface.LogMode(level),
+		config:    l.config,
+	}
+}
+
+func (l *tracingLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	l.Interface.Info(ctx, traceFields(ctx)+msg, data...)
+}
+
+func (l *tracingLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	l.Interface.Warn(ctx, traceFields(ctx)+msg, data...)
+}
+
+func (l *tracingLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	l.Interface.Error(ctx, traceFields(ctx)+msg, data...)
+}
+
+func (l *tracingLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	var (
+		sql          string
+		rowsAffected int64
+		called       bool
+	)
+
+	// fc builds the statement, call it at most once
+	statement := func() (string, int64) {
+		if !called {
+			sql, rowsAffected = fc()
+			called = true
+		}
+
+		return sql, rowsAffected
+	}
+
+	fields := traceFields(ctx)
+
+	l.Interface.Trace(ctx, begin, func() (string, int64) {
+		sql, rowsAffected := statement()
+
+		return fields + sql, rowsAffected
+	}, err)
+
+	elapsed := time.Since(begin)
+
+	if annotation := l.config.annotation(err, elapsed); annotation != "" {
+		var attributes []trace.Attribute
+		if annotation == QueryErrorAnnotation {
+			attributes = append(attributes, trace.StringAttribute(errorAttribute, err.Error()))
+		}
+
+		sql, rowsAffected := statement()
+
+		annotateQuery(ctx, annotation, elapsed, sql, rowsAffected, attributes...)
+	}
+}
+
+// traceFields formats the ids of the span found in the context.
+func traceFields(ctx context.Context) string {
+	span := ocotel.FromContext(ctx)
+	if span == nil {
+		return ""
+	}
+
+	sc := span.SpanContext()
+
+	return fmt.Sprintf("trace_id=%s span_id=%s ", sc.TraceID, sc.SpanID)
+}
+
+type slogLogger struct {
+	logger *slog.Logger
+	config LoggerConfig
+}
+
+// NewSlogLogger returns a gorm logger writing to the slog logger. The trace_id and span_id of the span
+// found in the context are added as attributes (the handler is wrapped with ocslog.NewHandler),
+// and the source of the records is the application code issuing the query.
+func NewSlogLogger(l *slog.Logger, config LoggerConfig) logger.Interface {
+	if config.LogLevel == 0 {
+		config.LogLevel = logger.Warn
+	}
+
+	return &slogLogger{
+		logger: slog.New(ocslog.NewHandler(l.Handler())),
+		config: config,
+	}
+}
+
+func (l *slogLogger) LogMode(level logger.LogLevel) logger.Interface {
+	config := l.config
+	config.LogLevel = level
+
+	return &slogLogger{
+		logger: l.logger,
+		config: config,
+	}
+}
+
+func (l *slogLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.config.LogLevel >= logger.Info {
+		l.log(ctx, slog.LevelInfo, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *slogLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.config.LogLevel >= logger.Warn {
+		l.log(ctx, slog.LevelWarn, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *slogLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.config.LogLevel >= logger.Error {
+		l.log(ctx, slog.LevelError, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *slogLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	elapsed := time.Since(begin)
+	notFound := errors.Is(err, gorm.ErrRecordNotFound)
+	slow := l.config.SlowThreshold > 0 && elapsed > l.config.SlowThreshold
+
+	var (
+		level   slog.Level
+		message string
+		logged  = true
+	)
+
+	switch {
+	case err != nil && !(notFound && l.config.IgnoreRecordNotFoundError) && l.config.LogLevel >= logger.Error:
+		level, message = slog.LevelError, QueryErrorAnnotation
+	case slow && l.config.LogLevel >= logger.Warn:
+		level, message = slog.LevelWarn, SlowQueryAnnotation
+	case l.config.LogLevel >= logger.Info:
+		level, message = slog.LevelInfo, QueryLog
+	default:
+		logged = false
+	}
+
+	annotation := l.config.annotation(err, elapsed)
+
+	if !logged && annotation == "" {
+		return
+	}
+
+	sql, rowsAffected := fc()
+
+	if logged {
+		attrs := []slog.Attr{
+			slog.String(statementAttribute, sql),
+			slog.Float64(durationAttribute, float64(elapsed.Nanoseconds())/1e6),
+			slog.Int64(rowsAffectedAttribute, rowsAffected),
+		}
+
+		if err != nil {
+			attrs = append(attrs, slog.String(errorAttribute, err.Error()))
+		}
+
+		l.log(ctx, level, message, attrs...)
+	}
+
+	if annotation != "" {
+		var attributes []trace.Attribute
+		if annotation == QueryErrorAnnotation {
+			attributes = append(attributes, trace.StringAttribute(errorAttribute, err.Error()))
+		}
+
+		annotateQuery(ctx, annotation, elapsed, sql, rowsAffected, attributes...)
+	}
+}
+
+// log records the message with the application code issuing the query as source.
+func (l *slogLogger) log(ctx context.Context, level slog.Level, msg string, attrs ...slog.Attr) {
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+
+	pc, _ := caller.FindPC()
+
+	r := slog.NewRecord(time.Now(), level, msg, pc)
+	r.AddAttrs(attrs...)
+
+	_ = l.logger.Handler().Handle(ctx, r)
+}
+
+func annotateQuery(ctx context.Context, message string, elapsed time.Duration, sql string, rowsAffected int64, attributes ...trace.Attribute) {
+	span := trace.FromContext(ctx)
+	if parent, ok := core.ParentSpanFromContext(ctx); ok {
+		span = parent
+	}
+
+	if !span.IsRecordingEvents() {
+		return
+	}
+
+	span.Annotate(
+		append(
+			attributes,
+			trace.StringAttribute(ocgorm.FingerprintAttribute, ocgorm.Fingerprint(sql)),
+			trace.Float64Attribute(durationAttribute, float64(elapsed.Nanoseconds())/1e6),
+			trace.Int64Attribute(rowsAffectedAttribute, rowsAffected),
+		),
+		message,
+	)
+}
@@ -0,0 +1,102 @@
+package ocgormv2_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opencensus.io/trace"
+	"gorm.io/gorm/logger"
+
+	"github.com/hashicorp/go-gin-gorm-opencensus/pkg/ocgormv2"
+)
+
+func TestLogger(t *testing.T) {
+	ctx, span := trace.StartSpan(context.Background(), "test", trace.WithSampler(trace.AlwaysSample()))
+	defer span.End()
+
+	sc := span.SpanContext()
+	fields := "trace_id=" + sc.TraceID.String() + " span_id=" + sc.SpanID.String() + " "
+
+	tests := []struct {
+		name string
+		log  func(l logger.Interface, fc func() (string, int64))
+		want string
+	}{
+		{
+			name: "info",
+			log: func(l logger.Interface, _ func() (string, int64)) {
+				l.Info(ctx, "migrating %s", "users")
+			},
+			want: fields + "migrating users",
+		},
+		{
+			name: "error",
+			log: func(l logger.Interface, _ func() (string, int64)) {
+				l.Error(ctx, "failed: %v", errors.New("boom"))
+			},
+			want: fields + "failed: boom",
+		},
+		{
+			name: "trace",
+			log: func(l logger.Interface, fc func() (string, int64)) {
+				l.Trace(ctx, time.Now(), fc, nil)
+			},
+			want: fields + "SELECT 1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			inner := logger.New(log.New(&buf, "", 0), logger.Config{LogLevel: logger.Info})
+			l := ocgormv2.NewLogger(inner, ocgormv2.LoggerConfig{SlowThreshold: time.Nanosecond})
+
+			var calls int
+
+			test.log(l, func() (string, int64) {
+				calls++
+
+				return "SELECT 1", 1
+			})
+
+			if !strings.Contains(buf.String(), test.want) {
+				t.Errorf("expected the log to contain %q, got %q", test.want, buf.String())
+			}
+
+			if calls > 1 {
+				t.Errorf("expected the statement to be built once, got %d calls", calls)
+			}
+		})
+	}
+}
+
+func TestSlogLogger(t *testing.T) {
+	ctx, span := trace.StartSpan(context.Background(), "test", trace.WithSampler(trace.AlwaysSample()))
+	defer span.End()
+
+	var buf bytes.Buffer
+
+	l := ocgormv2.NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, nil)), ocgormv2.LoggerConfig{LogLevel: logger.Info})
+	l.Trace(ctx, time.Now(), func() (string, int64) { return "SELECT 1", 1 }, nil)
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+
+	if record["trace_id"] != span.SpanContext().TraceID.String() {
+		t.Errorf("expected the trace_id attribute, got %v", record)
+	}
+
+	if record["db.statement"] != "SELECT 1" {
+		t.Errorf("expected the statement attribute, got %v", record)
+	}
+}
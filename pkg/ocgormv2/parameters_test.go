@@ -0,0 +1,108 @@
+package ocgormv2_test
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"go.opencensus.io/trace"
+	"gorm.io/gorm"
+
+	"github.com/hashicorp/go-gin-gorm-opencensus/pkg/ocgorm"
+	"github.com/hashicorp/go-gin-gorm-opencensus/pkg/ocgormv2"
+)
+
+type credential struct {
+	ID       int
+	Login    string
+	Password string
+}
+
+func TestParametersRedaction(t *testing.T) {
+	recorder := &spanRecorder{}
+	trace.RegisterExporter(recorder)
+	defer trace.UnregisterExporter(recorder)
+
+	db, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.AutoMigrate(&credential{}); err != nil {
+		t.Fatal(err)
+	}
+
+	redaction := ocgorm.ParameterRedaction{Columns: []*regexp.Regexp{regexp.MustCompile(`(?i)password`)}}
+	if err := ocgormv2.RegisterCallbacks(db, ocgormv2.Parameters(redaction)); err != nil {
+		t.Fatal(err)
+	}
+
+	const secret = "hunter2"
+
+	tests := []struct {
+		name  string
+		query func(db *gorm.DB) error
+	}{
+		{
+			name: "create",
+			query: func(db *gorm.DB) error {
+				return db.Create(&credential{Login: "alice", Password: secret}).Error
+			},
+		},
+		{
+			name: "where",
+			query: func(db *gorm.DB) error {
+				return db.Where("password = ?", secret).Find(&[]credential{}).Error
+			},
+		},
+		{
+			name: "function argument",
+			query: func(db *gorm.DB) error {
+				return db.Where("password = lower(?)", secret).Find(&[]credential{}).Error
+			},
+		},
+		{
+			name: "backslash escape",
+			query: func(db *gorm.DB) error {
+				return db.Where(`login <> 'it\'s' AND password = ?`, secret).Find(&[]credential{}).Error
+			},
+		},
+		{
+			name: "unresolved column",
+			query: func(db *gorm.DB) error {
+				return db.Raw("SELECT ? AS login", secret).Scan(&credential{}).Error
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, root := trace.StartSpan(context.Background(), "test", trace.WithSampler(trace.AlwaysSample()))
+
+			// Failing statements are recorded too
+			_ = test.query(db.WithContext(ctx))
+
+			root.End()
+
+			var recorded bool
+
+			for _, s := range recorder.trace(root.SpanContext().TraceID) {
+				_, ok := s.Attributes[ocgorm.ParametersAttribute]
+				recorded = recorded || ok
+
+				for key, value := range s.Attributes {
+					if strings.Contains(fmt.Sprint(value), secret) {
+						t.Errorf("expected %s of span %q to be redacted, got %v", key, s.Name, value)
+					}
+				}
+			}
+
+			if !recorded {
+				t.Errorf("expected the parameters to be recorded")
+			}
+		})
+	}
+}
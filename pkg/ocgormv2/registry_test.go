@@ -0,0 +1,131 @@
+package ocgormv2_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"contrib.go.opencensus.io/exporter/prometheus"
+	"github.com/glebarez/sqlite"
+	"go.opencensus.io/metric/metricproducer"
+	"go.opencensus.io/stats/view"
+	"gorm.io/gorm"
+
+	"github.com/hashicorp/go-gin-gorm-opencensus/pkg/ocgormv2"
+	"github.com/hashicorp/go-gin-gorm-opencensus/pkg/ocprometheus"
+)
+
+func TestPoolRegistryWithDefaultViews(t *testing.T) {
+	exporter, err := ocprometheus.NewExporter(prometheus.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer view.Unregister(ocprometheus.DefaultViews...)
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registry := ocgormv2.NewPoolRegistry()
+	defer registry.Close()
+
+	registry.Register(db, "registry")
+
+	stop := ocgormv2.RecordStats(db, 10*time.Millisecond, "views")
+	defer stop()
+
+	metrics := []string{
+		`go_sql_db_connections_open{database_name="views"}`,
+		`go_sql_db_connections_wait_count_total{database_name="views"}`,
+		`go_sql_db_pool_open{database_name="registry"}`,
+		`go_sql_db_pool_wait_count{database_name="registry"}`,
+	}
+
+	var (
+		body    string
+		missing []string
+	)
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		recorder := httptest.NewRecorder()
+		exporter.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, ocprometheus.DefaultPath, nil))
+
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("scrape failed with status %d: %s", recorder.Code, recorder.Body.String())
+		}
+
+		b, err := io.ReadAll(recorder.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body = string(b)
+
+		missing = missing[:0]
+		for _, metric := range metrics {
+			if !strings.Contains(body, metric) {
+				missing = append(missing, metric)
+			}
+		}
+
+		if len(missing) == 0 {
+			return
+		}
+	}
+
+	t.Fatalf("expected the scrape to contain %v, got:\n%s", missing, body)
+}
+
+func TestPoolRegistryStartTime(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registry := ocgormv2.NewPoolRegistry()
+	defer registry.Close()
+
+	registry.Register(db, "start")
+
+	beforeRead := time.Now()
+	first := waitCountStartTime(t, "start")
+
+	if first.Before(beforeRead) {
+		t.Errorf("expected the start time %s to be set by the first read at %s", first, beforeRead)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	registry.Register(db, "start")
+
+	if start := waitCountStartTime(t, "start"); !start.Equal(first) {
+		t.Errorf("expected the start time %s to be kept after registering the database again, got %s", first, start)
+	}
+}
+
+// waitCountStartTime returns the start time of the wait count of the database read by the global producers.
+func waitCountStartTime(t *testing.T, name string) time.Time {
+	t.Helper()
+
+	for _, producer := range metricproducer.GlobalManager().GetAll() {
+		for _, m := range producer.Read() {
+			if m.Descriptor.Name != "go.sql/db/pool/wait_count" {
+				continue
+			}
+
+			for _, series := range m.TimeSeries {
+				if series.LabelValues[0].Value == name {
+					return series.StartTime
+				}
+			}
+		}
+	}
+
+	t.Fatalf("expected the wait count of %s to be read", name)
+
+	return time.Time{}
+}
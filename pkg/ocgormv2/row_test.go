@@ -0,0 +1,131 @@
+package ocgormv2_test
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"go.opencensus.io/trace"
+	"gorm.io/gorm"
+
+	"github.com/hashicorp/go-gin-gorm-opencensus/pkg/ocgormv2"
+)
+
+type rowModel struct {
+	ID int
+}
+
+// spanRecorder collects the OpenCensus spans ended during a test.
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []*trace.SpanData
+}
+
+func (r *spanRecorder) ExportSpan(s *trace.SpanData) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.spans = append(r.spans, s)
+}
+
+// trace returns the spans of the trace.
+func (r *spanRecorder) trace(traceID trace.TraceID) []*trace.SpanData {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var spans []*trace.SpanData
+
+	for _, s := range r.spans {
+		if s.TraceID == traceID {
+			spans = append(spans, s)
+		}
+	}
+
+	return spans
+}
+
+// names returns the names of the spans of the trace, except its root.
+func (r *spanRecorder) names(traceID trace.TraceID, root trace.SpanID) []string {
+	var names []string
+
+	for _, s := range r.trace(traceID) {
+		if s.SpanID != root {
+			names = append(names, s.Name)
+		}
+	}
+
+	return names
+}
+
+func TestRowCallbacks(t *testing.T) {
+	recorder := &spanRecorder{}
+	trace.RegisterExporter(recorder)
+	defer trace.UnregisterExporter(recorder)
+
+	db, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.AutoMigrate(&rowModel{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ocgormv2.RegisterCallbacks(db); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		query func(db *gorm.DB) error
+		spans []string
+	}{
+		{
+			name: "find",
+			query: func(db *gorm.DB) error {
+				return db.Find(&[]rowModel{}).Error
+			},
+			spans: []string{"gorm:query"},
+		},
+		{
+			name: "row",
+			query: func(db *gorm.DB) error {
+				var count int
+
+				return db.Model(&rowModel{}).Select("count(*)").Row().Scan(&count)
+			},
+			spans: []string{"gorm:row_query"},
+		},
+		{
+			name: "rows",
+			query: func(db *gorm.DB) error {
+				rows, err := db.Model(&rowModel{}).Rows()
+				if err != nil {
+					return err
+				}
+
+				return rows.Close()
+			},
+			spans: []string{"gorm:row_query"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, root := trace.StartSpan(context.Background(), "test", trace.WithSampler(trace.AlwaysSample()))
+
+			if err := test.query(db.WithContext(ctx)); err != nil {
+				t.Fatal(err)
+			}
+
+			root.End()
+
+			sc := root.SpanContext()
+			if spans := recorder.names(sc.TraceID, sc.SpanID); !reflect.DeepEqual(spans, test.spans) {
+				t.Errorf("expected spans %q, got %q", test.spans, spans)
+			}
+		})
+	}
+}
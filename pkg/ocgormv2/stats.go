@@ -4,23 +4,53 @@
 package ocgormv2
 
 import (
-	"context"
-	"go.opencensus.io/tag"
-	"strings"
-	"sync"
 	"time"
 
-	"go.opencensus.io/stats"
 	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
 	"gorm.io/gorm"
 
+	"github.com/hashicorp/go-gin-gorm-opencensus/internal/core"
 	"github.com/hashicorp/go-gin-gorm-opencensus/pkg/ocgorm"
 )
 
 var (
 	DefaultViews = ocgorm.DefaultViews
+
+	// ModelViews aggregate stats by model, register them when using the ModelTag option.
+	ModelViews = ocgorm.ModelViews
 )
 
+// StatsConfig builds the views of the package with custom names, tags and buckets.
+type StatsConfig = ocgorm.StatsConfig
+
+// Stats records the measures with the tag keys of the configuration.
+func Stats(s StatsConfig) Option {
+	return ocgorm.Stats(s)
+}
+
+// ContextTags records the values of tag keys set upstream in the context of the queries
+// with the latency and calls measures, see ocgorm.ContextTags.
+func ContextTags(keys ...tag.Key) Option {
+	return ocgorm.ContextTags(keys...)
+}
+
+// CardinalityLimiter caps the number of distinct values recorded per tag key, see ocgorm.CardinalityLimiter.
+type CardinalityLimiter = ocgorm.CardinalityLimiter
+
+// NormalizationRule rewrites the tag values matching a pattern, see ocgorm.NormalizationRule.
+type NormalizationRule = ocgorm.NormalizationRule
+
+// NewCardinalityLimiter returns a limiter keeping maxValues distinct values per tag key.
+func NewCardinalityLimiter(maxValues int, rules ...NormalizationRule) *CardinalityLimiter {
+	return ocgorm.NewCardinalityLimiter(maxValues, rules...)
+}
+
+// CardinalityLimit bounds the values of the tags recorded with the measures (operation, table and model).
+func CardinalityLimit(l *CardinalityLimiter) Option {
+	return ocgorm.CardinalityLimit(l)
+}
+
 // RegisterAllViews registers all ocgorm views to enable collection of stats.
 func RegisterAllViews() {
 	if err := view.Register(DefaultViews...); err != nil {
@@ -31,51 +61,7 @@ func RegisterAllViews() {
 // RecordStats records database statistics for provided sql.DB at the provided
 // interval. You should defer execution of this function after you establish
 // connection to the database `if err == nil { ocgorm.RecordStats(db, 5*time.Second); }
-func RecordStats(db *gorm.DB, interval time.Duration, name string) (fnStop func()) {
-	var (
-		closeOnce sync.Once
-		ctx       = context.Background()
-		ticker    = time.NewTicker(interval)
-		done      = make(chan struct{})
-	)
-
-	go func() {
-		for {
-			select {
-			case <-ticker.C:
-				sqlDB, err := db.DB()
-				if err != nil {
-					return
-				}
-				dbStats := sqlDB.Stats()
-
-				if dbStats.OpenConnections == 0 { // We cleanup the ticker in the event that the database is unavailable
-					if err := sqlDB.Ping(); err != nil && strings.Contains(err.Error(), "database is closed") {
-						ticker.Stop()
-						return
-					}
-				}
-
-				stats.RecordWithTags(ctx,
-					[]tag.Mutator{tag.Upsert(ocgorm.DatabaseName, name)},
-					ocgorm.MeasureOpenConnections.M(int64(dbStats.OpenConnections)),
-					ocgorm.MeasureIdleConnections.M(int64(dbStats.Idle)),
-					ocgorm.MeasureActiveConnections.M(int64(dbStats.InUse)),
-					ocgorm.MeasureWaitCount.M(dbStats.WaitCount),
-					ocgorm.MeasureWaitDuration.M(float64(dbStats.WaitDuration.Nanoseconds())/1e6),
-					ocgorm.MeasureIdleClosed.M(dbStats.MaxIdleClosed),
-					ocgorm.MeasureLifetimeClosed.M(dbStats.MaxLifetimeClosed),
-				)
-			case <-done:
-				ticker.Stop()
-				return
-			}
-		}
-	}()
-
-	return func() {
-		closeOnce.Do(func() {
-			close(done)
-		})
-	}
+// Only the Stats option is used.
+func RecordStats(db *gorm.DB, interval time.Duration, name string, opts ...Option) (fnStop func()) {
+	return core.RecordStats(db.DB, interval, name, opts...)
 }
@@ -0,0 +1,110 @@
+package ocgormv2_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"gorm.io/gorm"
+
+	"github.com/hashicorp/go-gin-gorm-opencensus/pkg/ocgorm"
+	"github.com/hashicorp/go-gin-gorm-opencensus/pkg/ocgormv2"
+)
+
+type tenantModel struct {
+	ID int
+}
+
+func TestContextTags(t *testing.T) {
+	tenant := tag.MustNewKey("tenant")
+
+	tests := []struct {
+		name string
+		opt  func(s ocgormv2.StatsConfig) ocgormv2.Option
+	}{
+		{
+			name: "context tags",
+			opt: func(s ocgormv2.StatsConfig) ocgormv2.Option {
+				return ocgormv2.ContextTags(tenant)
+			},
+		},
+		{
+			name: "stats",
+			opt:  ocgormv2.Stats,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Custom names keep the views apart from the default ones registered by other tests
+			s := ocgormv2.StatsConfig{
+				Names: map[string]string{
+					ocgorm.SQLClientLatencyView.Name: "test/" + test.name + "/latency",
+					ocgorm.SQLClientCallsView.Name:   "test/" + test.name + "/calls",
+				},
+				ExtraTags: []tag.Key{tenant},
+			}
+
+			var views []*view.View
+
+			for _, v := range s.DefaultViews() {
+				if strings.HasPrefix(v.Name, "test/") {
+					views = append(views, v)
+				}
+			}
+
+			if err := view.Register(views...); err != nil {
+				t.Fatal(err)
+			}
+			defer view.Unregister(views...)
+
+			db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := db.AutoMigrate(&tenantModel{}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := ocgormv2.RegisterCallbacks(db, ocgormv2.AllowRoot(true), test.opt(s)); err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, err := tag.New(context.Background(), tag.Upsert(tenant, "acme"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := db.WithContext(ctx).Find(&[]tenantModel{}).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			for _, v := range views {
+				rows, err := view.RetrieveData(v.Name)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if len(rows) != 1 {
+					t.Fatalf("expected 1 row in %s, got %d", v.Name, len(rows))
+				}
+
+				var value string
+
+				for _, tag := range rows[0].Tags {
+					if tag.Key == tenant {
+						value = tag.Value
+					}
+				}
+
+				if value != "acme" {
+					t.Errorf("expected %s to be tagged with the tenant, got %v", v.Name, rows[0].Tags)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package ocprometheus
+
+import (
+	"contrib.go.opencensus.io/exporter/prometheus"
+	"github.com/gin-gonic/gin"
+	"go.opencensus.io/plugin/ochttp"
+	"go.opencensus.io/stats/view"
+
+	"github.com/hashicorp/go-gin-gorm-opencensus/pkg/ocgin"
+	"github.com/hashicorp/go-gin-gorm-opencensus/pkg/ocgorm"
+)
+
+// DefaultPath is the usual path of the Prometheus metrics route.
+const DefaultPath = "/metrics"
+
+// DefaultViews are the views exported to Prometheus by Mount.
+var DefaultViews = views(ocgorm.DefaultViews)
+
+// Views returns DefaultViews with the ocgorm views built with the configuration (eg. with ExtraTags).
+func Views(s ocgorm.StatsConfig) []*view.View {
+	return views(s.DefaultViews())
+}
+
+func views(gormViews []*view.View) []*view.View {
+	views := make([]*view.View, 0, len(gormViews)+len(ocgin.DefaultViews)+len(ochttp.DefaultServerViews))
+	views = append(views, gormViews...)
+	views = append(views, ocgin.DefaultViews...)
+	views = append(views, ochttp.DefaultServerViews...)
+
+	return views
+}
+
+// NewExporter registers the views, DefaultViews if none, and returns a Prometheus exporter for them.
+func NewExporter(o prometheus.Options, views ...*view.View) (*prometheus.Exporter, error) {
+	if len(views) == 0 {
+		views = DefaultViews
+	}
+
+	if err := view.Register(views...); err != nil {
+		return nil, err
+	}
+
+	return prometheus.NewExporter(o)
+}
+
+// Mount creates a Prometheus exporter with NewExporter and serves it on the router at the given path.
+func Mount(router gin.IRoutes, path string, o prometheus.Options, views ...*view.View) (*prometheus.Exporter, error) {
+	exporter, err := NewExporter(o, views...)
+	if err != nil {
+		return nil, err
+	}
+
+	router.GET(path, gin.WrapH(exporter))
+
+	return exporter, nil
+}
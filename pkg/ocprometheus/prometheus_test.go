@@ -0,0 +1,95 @@
+package ocprometheus_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"contrib.go.opencensus.io/exporter/prometheus"
+	"github.com/gin-gonic/gin"
+	"go.opencensus.io/plugin/ochttp"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/hashicorp/go-gin-gorm-opencensus/pkg/ocgin"
+	"github.com/hashicorp/go-gin-gorm-opencensus/pkg/ocgorm"
+	"github.com/hashicorp/go-gin-gorm-opencensus/pkg/ocprometheus"
+)
+
+func TestMount(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.Use(ocgin.Recovery(nil))
+	engine.GET("/ping", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	engine.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	if _, err := ocprometheus.Mount(engine, ocprometheus.DefaultPath, prometheus.Options{}); err != nil {
+		t.Fatal(err)
+	}
+	defer view.Unregister(ocprometheus.DefaultViews...)
+
+	handler := &ochttp.Handler{Handler: engine}
+
+	for _, path := range []string{"/ping", "/panic"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if err := stats.RecordWithTags(
+		context.Background(),
+		[]tag.Mutator{tag.Upsert(ocgorm.Operation, "query"), tag.Upsert(ocgorm.Table, "models")},
+		ocgorm.MeasureLatencyMs.M(1),
+		ocgorm.MeasureQueryCount.M(1),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	// The OpenCensus exporter does not suffix counters with _total
+	metrics := []string{
+		"# TYPE go_sql_client_calls counter",
+		`go_sql_client_calls{sql_operation="query",sql_table="models"} 1`,
+		"# TYPE go_sql_client_latency histogram",
+		`go_sql_client_latency_bucket{sql_operation="query",sql_table="models",le="1.5"} 1`,
+		"# TYPE opencensus_io_http_server_request_count counter",
+		"opencensus_io_http_server_request_count 2",
+		"# TYPE gin_server_panics counter",
+		`gin_server_panics{http_server_route="/panic"} 1`,
+	}
+
+	var body string
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, ocprometheus.DefaultPath, nil))
+
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("scrape failed with status %d: %s", recorder.Code, recorder.Body.String())
+		}
+
+		body = recorder.Body.String()
+
+		if containsAll(body, metrics) {
+			return
+		}
+	}
+
+	t.Fatalf("expected the scrape to contain %v, got:\n%s", metrics, body)
+}
+
+func containsAll(s string, substrs []string) bool {
+	for _, substr := range substrs {
+		if !strings.Contains(s, substr) {
+			return false
+		}
+	}
+
+	return true
+}
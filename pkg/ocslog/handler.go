@@ -0,0 +1,85 @@
+package ocslog
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/hashicorp/go-gin-gorm-opencensus/internal/ocotel"
+)
+
+// Keys of the attributes added to log records
+const (
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+	SampledKey = "sampled"
+)
+
+type handler struct {
+	slog.Handler
+
+	// root is the wrapped handler with the attributes added before the first group,
+	// ops replay the attributes and groups added since so that the ids stay at the top level.
+	root slog.Handler
+	ops  []func(h slog.Handler) slog.Handler
+}
+
+// NewHandler wraps a slog.Handler to add the ids of the OpenCensus span
+// found in the record context to each record, outside of any group.
+func NewHandler(h slog.Handler) slog.Handler {
+	if _, ok := h.(*handler); ok {
+		return h
+	}
+
+	return &handler{Handler: h, root: h}
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	span := ocotel.FromContext(ctx)
+	if span == nil {
+		return h.Handler.Handle(ctx, r)
+	}
+
+	sc := span.SpanContext()
+
+	traced := h.root.WithAttrs([]slog.Attr{
+		slog.String(TraceIDKey, sc.TraceID.String()),
+		slog.String(SpanIDKey, sc.SpanID.String()),
+		slog.Bool(SampledKey, sc.IsSampled()),
+	})
+
+	for _, op := range h.ops {
+		traced = op(traced)
+	}
+
+	return traced.Handle(ctx, r)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(h.ops) == 0 {
+		withAttrs := h.Handler.WithAttrs(attrs)
+
+		return &handler{Handler: withAttrs, root: withAttrs}
+	}
+
+	return h.with(h.Handler.WithAttrs(attrs), func(h slog.Handler) slog.Handler {
+		return h.WithAttrs(attrs)
+	})
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	return h.with(h.Handler.WithGroup(name), func(h slog.Handler) slog.Handler {
+		return h.WithGroup(name)
+	})
+}
+
+// with returns a handler replaying the operations of h followed by op.
+func (h *handler) with(next slog.Handler, op func(h slog.Handler) slog.Handler) *handler {
+	ops := make([]func(h slog.Handler) slog.Handler, 0, len(h.ops)+1)
+	ops = append(ops, h.ops...)
+
+	return &handler{Handler: next, root: h.root, ops: append(ops, op)}
+}
@@ -0,0 +1,85 @@
+package ocslog_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"go.opencensus.io/trace"
+
+	"github.com/hashicorp/go-gin-gorm-opencensus/pkg/ocslog"
+)
+
+func TestHandler(t *testing.T) {
+	ctx, span := trace.StartSpan(context.Background(), "request", trace.WithSampler(trace.AlwaysSample()))
+	defer span.End()
+
+	sc := span.SpanContext()
+
+	tests := []struct {
+		name   string
+		logger func(h slog.Handler) *slog.Logger
+		args   []interface{}
+		record map[string]interface{}
+	}{
+		{
+			name: "attributes",
+			logger: func(h slog.Handler) *slog.Logger {
+				return slog.New(h).With("user", "alice")
+			},
+			record: map[string]interface{}{
+				"user": "alice",
+				"msg":  "query",
+			},
+		},
+		{
+			name: "group with attributes",
+			logger: func(h slog.Handler) *slog.Logger {
+				return slog.New(h.WithGroup("g").WithAttrs([]slog.Attr{slog.String("user", "alice")}))
+			},
+			args: []interface{}{"rows", 1},
+			record: map[string]interface{}{
+				"msg": "query",
+				"g": map[string]interface{}{
+					"user": "alice",
+					"rows": float64(1),
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			handler := ocslog.NewHandler(slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+				ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+					// Drop the time and level to compare the records
+					if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey) {
+						return slog.Attr{}
+					}
+
+					return a
+				},
+			}))
+
+			test.logger(handler).InfoContext(ctx, "query", test.args...)
+
+			var record map[string]interface{}
+			if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+				t.Fatal(err)
+			}
+
+			test.record[ocslog.TraceIDKey] = sc.TraceID.String()
+			test.record[ocslog.SpanIDKey] = sc.SpanID.String()
+			test.record[ocslog.SampledKey] = true
+
+			if !reflect.DeepEqual(record, test.record) {
+				t.Errorf("expected the record %v, got %v", test.record, record)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+// Package otelgin instruments Gin with OpenTelemetry.
+//
+// It mirrors the options of ocgin so that services can move from OpenCensus
+// to OpenTelemetry with the same configuration.
+package otelgin
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/hashicorp/go-gin-gorm-opencensus/internal/core"
+	"github.com/hashicorp/go-gin-gorm-opencensus/internal/servertiming"
+)
+
+// ScopeName is the instrumentation scope of the tracer and meter.
+const ScopeName = "github.com/hashicorp/go-gin-gorm-opencensus/pkg/otelgin"
+
+// ServerTimingHeader is the response header carrying the time spent in the database.
+const ServerTimingHeader = servertiming.Header
+
+// RequestDurationInstrument is the histogram of the request durations in seconds.
+const RequestDurationInstrument = "http.server.request.duration"
+
+// Attributes recorded on the request span, following the OpenTelemetry semantic conventions.
+const (
+	RequestMethodAttribute      = "http.request.method"
+	RouteAttribute              = "http.route"
+	URLPathAttribute            = "url.path"
+	ResponseStatusCodeAttribute = "http.response.status_code"
+)
+
+// PanicAnnotation is the name of the event added to the request span when a handler panics.
+const PanicAnnotation = "panic"
+
+// Option allows for managing otelgin configuration using functional options.
+type Option interface {
+	apply(m *middleware)
+}
+
+// OptionFunc converts a regular function to an Option if it's definition is compatible.
+type OptionFunc func(m *middleware)
+
+func (fn OptionFunc) apply(m *middleware) {
+	fn(m)
+}
+
+// ServerTiming allows emitting a Server-Timing header with the time spent in the database.
+type ServerTiming bool
+
+func (s ServerTiming) apply(m *middleware) {
+	m.serverTiming = bool(s)
+}
+
+// QueryBudget limits the number of queries run by each request, see otelgormv2.QueryBudget.
+func QueryBudget(b core.QueryBudget) Option {
+	return OptionFunc(func(m *middleware) {
+		m.queryBudget = b
+	})
+}
+
+// TracerProvider sets the provider of the tracer, the global provider is used by default.
+func TracerProvider(tp trace.TracerProvider) Option {
+	return OptionFunc(func(m *middleware) {
+		m.tracerProvider = tp
+	})
+}
+
+// MeterProvider sets the provider of the meter, the global provider is used by default.
+func MeterProvider(mp metric.MeterProvider) Option {
+	return OptionFunc(func(m *middleware) {
+		m.meterProvider = mp
+	})
+}
+
+// Propagators sets the propagators extracting the remote span context, the global ones are used by default.
+func Propagators(p propagation.TextMapPropagator) Option {
+	return OptionFunc(func(m *middleware) {
+		m.propagators = p
+	})
+}
+
+type middleware struct {
+	// Emit a Server-Timing header with the time spent in the database.
+	serverTiming bool
+
+	// queryBudget is applied to the queries run by each request.
+	queryBudget core.QueryBudget
+
+	// Providers of the tracer and the meter and the propagators, the global ones if nil.
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	propagators    propagation.TextMapPropagator
+
+	tracer   trace.Tracer
+	duration metric.Float64Histogram
+}
+
+// Middleware starts a server span around each request and prepares the request context
+// for the otelgormv2 instrumentation.
+//
+// The span is named after the matched route and the context is seeded for
+// query summaries, N+1 query detection and sqlcommenter tags.
+func Middleware(opts ...Option) gin.HandlerFunc {
+	m := &middleware{}
+
+	for _, opt := range opts {
+		opt.apply(m)
+	}
+
+	if m.tracerProvider == nil {
+		m.tracerProvider = otel.GetTracerProvider()
+	}
+
+	if m.meterProvider == nil {
+		m.meterProvider = otel.GetMeterProvider()
+	}
+
+	if m.propagators == nil {
+		m.propagators = otel.GetTextMapPropagator()
+	}
+
+	duration, err := m.meterProvider.Meter(ScopeName).Float64Histogram(RequestDurationInstrument,
+		metric.WithDescription("Duration of HTTP server requests"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		// The returned instrument is still usable, report the error to the global handler
+		otel.Handle(err)
+	}
+
+	m.tracer = m.tracerProvider.Tracer(ScopeName)
+	m.duration = duration
+
+	return m.handle
+}
+
+func (m *middleware) handle(c *gin.Context) {
+	start := time.Now()
+	route := c.FullPath()
+
+	spanName := route
+	if spanName == "" {
+		spanName = fmt.Sprintf("HTTP %s", c.Request.Method)
+	}
+
+	ctx := m.propagators.Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+	ctx, span := m.tracer.Start(ctx, spanName,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String(RequestMethodAttribute, c.Request.Method),
+			attribute.String(RouteAttribute, route),
+			attribute.String(URLPathAttribute, c.Request.URL.Path),
+		),
+	)
+
+	ctx = core.WithSQLCommentTags(ctx, core.SQLCommentTags{
+		Route:      route,
+		Controller: c.HandlerName(),
+	})
+	ctx = core.WithNPlusOneDetectionSpan(ctx, requestSpan{span})
+	ctx = core.WithQuerySummarySpan(ctx, m.queryBudget, requestSpan{span})
+
+	c.Request = c.Request.WithContext(ctx)
+
+	defer func() {
+		if err := recover(); err != nil {
+			span.AddEvent(PanicAnnotation, trace.WithAttributes(attribute.String("panic.value", fmt.Sprint(err))))
+			span.SetStatus(codes.Error, fmt.Sprint(err))
+			span.End()
+
+			// The panic is usually turned into a 500 by a recovery middleware
+			m.recordDuration(c, start, route, http.StatusInternalServerError)
+
+			panic(err)
+		}
+
+		status := c.Writer.Status()
+
+		span.SetAttributes(attribute.Int(ResponseStatusCodeAttribute, status))
+
+		for _, err := range c.Errors {
+			span.RecordError(err.Err)
+		}
+
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+
+		span.End()
+
+		m.recordDuration(c, start, route, status)
+	}()
+
+	if !m.serverTiming {
+		c.Next()
+
+		return
+	}
+
+	summary, _ := core.QuerySummaryFromContext(ctx)
+	writer := servertiming.NewWriter(c.Writer, summary)
+	c.Writer = writer
+
+	c.Next()
+
+	// Nothing was written by the handlers, Gin writes the headers after the middleware returns
+	writer.SetHeader()
+}
+
+func (m *middleware) recordDuration(c *gin.Context, start time.Time, route string, status int) {
+	m.duration.Record(c.Request.Context(), time.Since(start).Seconds(), metric.WithAttributes(
+		attribute.String(RequestMethodAttribute, c.Request.Method),
+		attribute.String(RouteAttribute, route),
+		attribute.Int(ResponseStatusCodeAttribute, status),
+	))
+}
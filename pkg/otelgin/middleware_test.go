@@ -0,0 +1,60 @@
+package otelgin_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/hashicorp/go-gin-gorm-opencensus/pkg/otelgin"
+)
+
+func TestMiddlewarePanic(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.Use(gin.Recovery(), otelgin.Middleware(otelgin.MeterProvider(mp)))
+	engine.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	response := httptest.NewRecorder()
+	engine.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	if response.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, response.Code)
+	}
+
+	var data metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, scope := range data.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if m.Name != otelgin.RequestDurationInstrument {
+				continue
+			}
+
+			points := m.Data.(metricdata.Histogram[float64]).DataPoints
+			if len(points) != 1 || points[0].Count != 1 {
+				t.Fatalf("expected a single request duration, got %+v", points)
+			}
+
+			if status, _ := points[0].Attributes.Value(otelgin.ResponseStatusCodeAttribute); status.AsInt64() != http.StatusInternalServerError {
+				t.Errorf("expected the status code %d, got %d", http.StatusInternalServerError, status.AsInt64())
+			}
+
+			return
+		}
+	}
+
+	t.Fatalf("expected the %s instrument to be recorded", otelgin.RequestDurationInstrument)
+}
@@ -0,0 +1,21 @@
+package otelgin
+
+import (
+	octrace "go.opencensus.io/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/hashicorp/go-gin-gorm-opencensus/internal/ocotel"
+)
+
+// requestSpan records the query summary and N+1 detection of ocgorm on the request span.
+type requestSpan struct {
+	span trace.Span
+}
+
+func (s requestSpan) Annotate(attributes []octrace.Attribute, message string) {
+	s.span.AddEvent(message, trace.WithAttributes(ocotel.KeyValues(attributes)...))
+}
+
+func (s requestSpan) AddAttributes(attributes ...octrace.Attribute) {
+	s.span.SetAttributes(ocotel.KeyValues(attributes)...)
+}
@@ -0,0 +1,264 @@
+// Package otelgormv2 instruments gorm v2 with OpenTelemetry.
+//
+// It shares the instrumentation and the options of ocgormv2 so that services can move from OpenCensus
+// to OpenTelemetry with the same configuration.
+package otelgormv2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.opencensus.io/tag"
+	octrace "go.opencensus.io/trace"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+
+	"github.com/hashicorp/go-gin-gorm-opencensus/internal/core"
+	"github.com/hashicorp/go-gin-gorm-opencensus/internal/gormv2"
+	"github.com/hashicorp/go-gin-gorm-opencensus/internal/ocotel"
+	"github.com/hashicorp/go-gin-gorm-opencensus/pkg/ocgorm"
+)
+
+// ScopeName is the instrumentation scope of the tracer and meter.
+const ScopeName = "github.com/hashicorp/go-gin-gorm-opencensus/pkg/otelgormv2"
+
+// CallbackPrefix prefixes the names of the gorm callbacks, so that otelgormv2 and ocgormv2
+// can instrument the same database.
+const CallbackPrefix = "otelgorm"
+
+// Attributes recorded on the span for the queries, following the OpenTelemetry semantic conventions.
+const (
+	DBSystemAttribute    = "db.system"
+	DBStatementAttribute = "db.statement"
+	DBOperationAttribute = "db.operation"
+)
+
+// Option allows for managing otelgormv2 configuration using functional options.
+// Options are shared with ocgormv2.
+type Option = core.Option
+
+// OptionFunc converts a regular function to an Option if it's definition is compatible.
+type OptionFunc = core.OptionFunc
+
+// AllowRoot allows creating root spans in the absence of existing spans.
+type AllowRoot = core.AllowRoot
+
+// Query allows recording the sql queries in spans.
+type Query = core.Query
+
+// Caller allows recording the location of the code issuing the sql queries in spans.
+type Caller = core.Caller
+
+// ModelTag allows recording the model name in the metrics.
+type ModelTag = core.ModelTag
+
+// MaxStatementLength truncates sql queries recorded in spans to the given length.
+// Truncated queries end with a marker.
+type MaxStatementLength = core.MaxStatementLength
+
+// MaxAttributesLength limits the total length of the string attributes recorded on each span.
+// Values exceeding the budget are truncated or dropped.
+type MaxAttributesLength = core.MaxAttributesLength
+
+// DefaultAttributes sets attributes to each span.
+func DefaultAttributes(attributes ...attribute.KeyValue) Option {
+	return core.DefaultAttributes(ocotel.Attributes(attributes))
+}
+
+// StartOptions configures the initial options applied to a span.
+// Root spans are sampled by the tracer provider, StartOptions.Sampler is not used.
+func StartOptions(o octrace.StartOptions) Option {
+	return ocgorm.StartOptions(o)
+}
+
+// Parameters allows recording the bind parameters of sql queries in spans.
+func Parameters(r ocgorm.ParameterRedaction) Option {
+	return ocgorm.Parameters(r)
+}
+
+// NPlusOne allows detecting queries repeated within a request seeded with WithNPlusOneDetectionSpan.
+func NPlusOne(d NPlusOneDetector) Option {
+	return OptionFunc(func(c *core.Config) {
+		c.NPlusOne = d
+	})
+}
+
+// SQLCommenter appends a sqlcommenter comment with the trace context and the
+// application name to each statement.
+func SQLCommenter(application string) Option {
+	return OptionFunc(func(c *core.Config) {
+		c.Commenter = core.SQLCommenter(application)
+	})
+}
+
+// DatadogConventions sets the span attributes expected by Datadog APM, see ocgorm.DatadogConventions.
+func DatadogConventions(d ocgorm.DatadogConfig) Option {
+	return ocgorm.DatadogConventions(d)
+}
+
+// ContextTags records the values of tag keys set upstream in the context of the queries
+// as attributes of the latency and calls instruments.
+func ContextTags(keys ...tag.Key) Option {
+	return ocgorm.ContextTags(keys...)
+}
+
+// CardinalityLimit bounds the values of the attributes recorded with the instruments
+// (operation, table and model), see ocgorm.CardinalityLimiter.
+func CardinalityLimit(l *ocgorm.CardinalityLimiter) Option {
+	return ocgorm.CardinalityLimit(l)
+}
+
+// TracerProvider sets the provider of the tracer, the global provider is used by default.
+func TracerProvider(tp trace.TracerProvider) Option {
+	return OptionFunc(func(c *core.Config) {
+		c.TracerProvider = tp
+	})
+}
+
+// MeterProvider sets the provider of the meter, the global provider is used by default.
+func MeterProvider(mp metric.MeterProvider) Option {
+	return OptionFunc(func(c *core.Config) {
+		c.MeterProvider = mp
+	})
+}
+
+// RegisterCallbacks registers the necessary callbacks in Gorm's hook system for instrumentation.
+func RegisterCallbacks(db *gorm.DB, opts ...Option) error {
+	config := newConfig(opts...)
+
+	instruments, err := newInstruments(config.MeterProvider.Meter(ScopeName))
+	if err != nil {
+		return err
+	}
+
+	config.Tracer = tracer{
+		tracer: config.TracerProvider.Tracer(ScopeName),
+		system: db.Dialector.Name(),
+	}
+	config.Metrics = instruments
+	config.QueryAttribute = DBStatementAttribute
+
+	return gormv2.Register(db, CallbackPrefix, core.NewFromConfig(db.Dialector.Name(), config))
+}
+
+func newConfig(opts ...Option) core.Config {
+	config := core.NewConfig(opts...)
+
+	if config.TracerProvider == nil {
+		config.TracerProvider = otel.GetTracerProvider()
+	}
+
+	if config.MeterProvider == nil {
+		config.MeterProvider = otel.GetMeterProvider()
+	}
+
+	return config
+}
+
+// tracer starts OpenTelemetry spans for the core instrumentation.
+type tracer struct {
+	tracer trace.Tracer
+
+	// system is the database management system, recorded as db.system
+	system string
+}
+
+func (t tracer) HasSpan(ctx context.Context) bool {
+	return trace.SpanContextFromContext(ctx).IsValid()
+}
+
+func (t tracer) StartSpan(ctx context.Context, operation string, _ bool, _ octrace.StartOptions) (context.Context, core.Span) {
+	ctx, s := t.tracer.Start(
+		ctx,
+		fmt.Sprintf("gorm:%s", operation),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String(DBSystemAttribute, t.system),
+			attribute.String(DBOperationAttribute, operation),
+		),
+	)
+
+	return ctx, span{s}
+}
+
+// span converts the OpenCensus attributes and status recorded by the core instrumentation.
+type span struct {
+	span trace.Span
+}
+
+func (s span) AddAttributes(attributes ...octrace.Attribute) {
+	s.span.SetAttributes(ocotel.KeyValues(attributes)...)
+}
+
+// SetStatus records errors other than a record not being found.
+func (s span) SetStatus(status octrace.Status) {
+	if status.Code == octrace.StatusCodeOK || status.Code == octrace.StatusCodeNotFound {
+		return
+	}
+
+	s.span.RecordError(errors.New(status.Message))
+	s.span.SetStatus(codes.Error, status.Message)
+}
+
+func (s span) SpanContext() octrace.SpanContext {
+	return ocotel.SpanContext(s.span.SpanContext())
+}
+
+func (s span) End() {
+	s.span.End()
+}
+
+type attributesKey struct{}
+
+// StartQuery stores the metric attributes of the query, named after the tag keys.
+func (i *instruments) StartQuery(ctx context.Context, labels []core.Label) context.Context {
+	attributes := make([]attribute.KeyValue, len(labels))
+	for j, label := range labels {
+		attributes[j] = attribute.String(label.Key.Name(), label.Value)
+	}
+
+	return context.WithValue(ctx, attributesKey{}, attribute.NewSet(attributes...))
+}
+
+func (i *instruments) EndQuery(ctx context.Context, duration time.Duration, labels []core.Label, _ core.Span) {
+	attributes := metricAttributes(ctx)
+
+	if len(labels) > 0 {
+		set, _ := ctx.Value(attributesKey{}).(attribute.Set)
+
+		kvs := set.ToSlice()
+		for _, label := range labels {
+			kvs = append(kvs, attribute.String(label.Key.Name(), label.Value))
+		}
+
+		attributes = metric.WithAttributes(kvs...)
+	}
+
+	i.latency.Record(ctx, float64(duration.Nanoseconds())/1e6, attributes)
+	i.calls.Add(ctx, 1, attributes)
+}
+
+func (i *instruments) TruncatedAttributes(ctx context.Context, truncated int64) {
+	i.truncatedAttributes.Add(ctx, truncated, metricAttributes(ctx))
+}
+
+func (i *instruments) NPlusOne(ctx context.Context) {
+	i.nPlusOne.Add(ctx, 1, metricAttributes(ctx))
+}
+
+func (i *instruments) RecordDroppedTag(ctx context.Context, key tag.Key) {
+	i.droppedTagValues.Add(ctx, 1, metric.WithAttributes(attribute.String(ocgorm.LimitedTag.Name(), key.Name())))
+}
+
+// metricAttributes returns the attributes stored by StartQuery.
+func metricAttributes(ctx context.Context) metric.MeasurementOption {
+	set, _ := ctx.Value(attributesKey{}).(attribute.Set)
+
+	return metric.WithAttributeSet(set)
+}
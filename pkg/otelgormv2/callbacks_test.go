@@ -0,0 +1,313 @@
+package otelgormv2_test
+
+import (
+	"context"
+	"testing"
+
+	"sync"
+
+	"github.com/glebarez/sqlite"
+	"go.opencensus.io/tag"
+	octrace "go.opencensus.io/trace"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/gorm"
+
+	"github.com/hashicorp/go-gin-gorm-opencensus/internal/truncate"
+	"github.com/hashicorp/go-gin-gorm-opencensus/pkg/ocgorm"
+	"github.com/hashicorp/go-gin-gorm-opencensus/pkg/ocgormv2"
+	"github.com/hashicorp/go-gin-gorm-opencensus/pkg/otelgormv2"
+)
+
+type tenantModel struct {
+	ID int
+}
+
+func TestContextTags(t *testing.T) {
+	tenant := tag.MustNewKey("tenant")
+
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.AutoMigrate(&tenantModel{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := otelgormv2.RegisterCallbacks(db, otelgormv2.MeterProvider(mp), otelgormv2.ContextTags(tenant)); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, err := tag.New(context.Background(), tag.Upsert(tenant, "acme"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.WithContext(ctx).Find(&[]tenantModel{}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var data metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	tagged := map[string]bool{}
+
+	for _, scope := range data.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			var sets []attribute.Set
+
+			switch d := m.Data.(type) {
+			case metricdata.Histogram[float64]:
+				for _, point := range d.DataPoints {
+					sets = append(sets, point.Attributes)
+				}
+			case metricdata.Sum[int64]:
+				for _, point := range d.DataPoints {
+					sets = append(sets, point.Attributes)
+				}
+			}
+
+			for _, set := range sets {
+				if value, ok := set.Value(attribute.Key(tenant.Name())); ok && value.AsString() == "acme" {
+					tagged[m.Name] = true
+				}
+			}
+		}
+	}
+
+	for _, name := range []string{otelgormv2.LatencyInstrument, otelgormv2.QueryCountInstrument} {
+		if !tagged[name] {
+			t.Errorf("expected %s to be tagged with the tenant", name)
+		}
+	}
+}
+
+// spanRecorder collects the OpenCensus spans ended during a test.
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []*octrace.SpanData
+}
+
+func (r *spanRecorder) ExportSpan(s *octrace.SpanData) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.spans = append(r.spans, s)
+}
+
+func TestRegisterCallbacksWithOCGormV2(t *testing.T) {
+	ocRecorder := &spanRecorder{}
+	octrace.RegisterExporter(ocRecorder)
+	defer octrace.UnregisterExporter(ocRecorder)
+
+	otelRecorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(otelRecorder))
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.AutoMigrate(&tenantModel{}); err != nil {
+		t.Fatal(err)
+	}
+
+	err = ocgormv2.RegisterCallbacks(db,
+		ocgormv2.AllowRoot(true),
+		ocgormv2.StartOptions(octrace.StartOptions{Sampler: octrace.AlwaysSample()}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := otelgormv2.RegisterCallbacks(db, otelgormv2.AllowRoot(true), otelgormv2.TracerProvider(tp)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Find(&[]tenantModel{}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if spans := otelRecorder.Ended(); len(spans) != 1 || spans[0].Name() != "gorm:query" {
+		t.Errorf("expected a gorm:query OpenTelemetry span, got %d spans", len(spans))
+	}
+
+	ocRecorder.mu.Lock()
+	defer ocRecorder.mu.Unlock()
+
+	if len(ocRecorder.spans) != 1 || ocRecorder.spans[0].Name != "gorm:query" {
+		t.Errorf("expected a gorm:query OpenCensus span, got %d spans", len(ocRecorder.spans))
+	}
+}
+
+func TestCardinalityLimit(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.AutoMigrate(&tenantModel{}); err != nil {
+		t.Fatal(err)
+	}
+
+	err = otelgormv2.RegisterCallbacks(db,
+		otelgormv2.MeterProvider(mp),
+		otelgormv2.CardinalityLimit(ocgorm.NewCardinalityLimiter(1)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, table := range []string{"tenant_models", "tenant_models", "tenant_models_archive"} {
+		// Errors are expected for the missing table, the value is limited before the query runs
+		_ = db.Table(table).Find(&[]tenantModel{}).Error
+	}
+
+	var data metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, scope := range data.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if m.Name != otelgormv2.DroppedTagValuesInstrument {
+				continue
+			}
+
+			points := m.Data.(metricdata.Sum[int64]).DataPoints
+			if len(points) != 1 || points[0].Value != 1 {
+				t.Fatalf("expected a single dropped value, got %+v", points)
+			}
+
+			if key, _ := points[0].Attributes.Value(attribute.Key(ocgorm.LimitedTag.Name())); key.AsString() != ocgorm.Table.Name() {
+				t.Errorf("expected the dropped value to be tagged with %s, got %s", ocgorm.Table.Name(), key.AsString())
+			}
+
+			return
+		}
+	}
+
+	t.Fatalf("expected the %s instrument to be recorded", otelgormv2.DroppedTagValuesInstrument)
+}
+
+func TestTruncation(t *testing.T) {
+	const sql = "SELECT 1 AS a_rather_long_column_alias_exceeding_the_limits"
+
+	tests := []struct {
+		name                string
+		maxStatementLength  int
+		maxAttributesLength int
+		statement           string
+		recorded            bool
+	}{
+		{
+			name:               "statement",
+			maxStatementLength: 30,
+			statement:          sql[:30-len(truncate.Marker)] + truncate.Marker,
+			recorded:           true,
+		},
+		{
+			name:                "statement truncated again by the attributes budget",
+			maxStatementLength:  40,
+			maxAttributesLength: 30,
+			statement:           sql[:30-len(truncate.Marker)] + truncate.Marker,
+			recorded:            true,
+		},
+		{
+			name:               "statement limit shorter than the marker",
+			maxStatementLength: 5,
+			statement:          truncate.Marker[:5],
+			recorded:           true,
+		},
+		{
+			name:                "attributes budget shorter than the marker",
+			maxAttributesLength: 5,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			spans := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+
+			reader := sdkmetric.NewManualReader()
+			mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+			db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = otelgormv2.RegisterCallbacks(db,
+				otelgormv2.AllowRoot(true),
+				otelgormv2.Query(true),
+				otelgormv2.MaxStatementLength(test.maxStatementLength),
+				otelgormv2.MaxAttributesLength(test.maxAttributesLength),
+				otelgormv2.TracerProvider(tp),
+				otelgormv2.MeterProvider(mp),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rows, err := db.Raw(sql).Rows()
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = rows.Close()
+
+			ended := spans.Ended()
+			if len(ended) != 1 {
+				t.Fatalf("expected a single span, got %d", len(ended))
+			}
+
+			var (
+				statement string
+				recorded  bool
+			)
+
+			for _, kv := range ended[0].Attributes() {
+				if kv.Key == otelgormv2.DBStatementAttribute {
+					statement, recorded = kv.Value.AsString(), true
+				}
+			}
+
+			if recorded != test.recorded || statement != test.statement {
+				t.Errorf("expected the statement %q (recorded: %t), got %q (recorded: %t)", test.statement, test.recorded, statement, recorded)
+			}
+
+			var data metricdata.ResourceMetrics
+			if err := reader.Collect(context.Background(), &data); err != nil {
+				t.Fatal(err)
+			}
+
+			var truncated int64
+
+			for _, scope := range data.ScopeMetrics {
+				for _, m := range scope.Metrics {
+					if m.Name == otelgormv2.TruncatedAttributesInstrument {
+						for _, point := range m.Data.(metricdata.Sum[int64]).DataPoints {
+							truncated += point.Value
+						}
+					}
+				}
+			}
+
+			if truncated != 1 {
+				t.Errorf("expected the statement to be counted once as truncated, got %d", truncated)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package otelgormv2
+
+import (
+	"context"
+
+	"github.com/hashicorp/go-gin-gorm-opencensus/internal/core"
+)
+
+// ErrQueryBudgetExceeded is returned for queries exceeding the request budget when QueryBudget.Fail is set.
+var ErrQueryBudgetExceeded = core.ErrQueryBudgetExceeded
+
+// QueryBudget limits the number of queries run within a request, see otelgin.QueryBudget.
+type QueryBudget = core.QueryBudget
+
+// RequestSpan records the events and totals of a request, an adapter of the request span.
+type RequestSpan = core.RequestSpan
+
+// QuerySummary accumulates the queries run within a request
+// and records the totals on the request span.
+type QuerySummary = core.QuerySummary
+
+// WithQuerySummarySpan seeds a request context with a query summary recorded on the span.
+func WithQuerySummarySpan(ctx context.Context, budget QueryBudget, span RequestSpan) context.Context {
+	return core.WithQuerySummarySpan(ctx, budget, span)
+}
+
+// QuerySummaryFromContext returns the query summary of the request, if any.
+func QuerySummaryFromContext(ctx context.Context) (*QuerySummary, bool) {
+	return core.QuerySummaryFromContext(ctx)
+}
+
+// NPlusOneDetector flags queries repeated within a request, usually caused by
+// loading associations one record at a time.
+type NPlusOneDetector = core.NPlusOneDetector
+
+// WithNPlusOneDetectionSpan seeds a request context for N+1 query detection.
+// The span is annotated when repeated queries are detected.
+func WithNPlusOneDetectionSpan(ctx context.Context, span RequestSpan) context.Context {
+	return core.WithNPlusOneDetectionSpan(ctx, span)
+}
+
+// SQLCommentTags are request scoped values appended to statements as a sqlcommenter comment.
+type SQLCommentTags = core.SQLCommentTags
+
+// WithSQLCommentTags sets the sqlcommenter tags in the context, usually from an HTTP middleware.
+func WithSQLCommentTags(ctx context.Context, tags SQLCommentTags) context.Context {
+	return core.WithSQLCommentTags(ctx, tags)
+}
@@ -0,0 +1,123 @@
+package otelgormv2
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"gorm.io/gorm"
+
+	"github.com/hashicorp/go-gin-gorm-opencensus/pkg/ocgorm"
+)
+
+// Instrument names, matching the ocgorm measures once exported (eg. go_sql_client_calls_total in Prometheus)
+const (
+	QueryCountInstrument          = "go.sql.client.calls"
+	LatencyInstrument             = "go.sql.client.latency"
+	TruncatedAttributesInstrument = "go.sql.client.truncated_attributes"
+	NPlusOneInstrument            = "go.sql.client.n_plus_one"
+	DroppedTagValuesInstrument    = "go.sql.client.dropped_tag_values"
+
+	OpenConnectionsInstrument   = "go.sql.connections.open"
+	IdleConnectionsInstrument   = "go.sql.connections.idle"
+	ActiveConnectionsInstrument = "go.sql.connections.active"
+	WaitCountInstrument         = "go.sql.connections.wait_count"
+	WaitDurationInstrument      = "go.sql.connections.wait_duration"
+	IdleClosedInstrument        = "go.sql.connections.idle_closed"
+	LifetimeClosedInstrument    = "go.sql.connections.lifetime_closed"
+)
+
+// instruments record the queries.
+type instruments struct {
+	calls               metric.Int64Counter
+	latency             metric.Float64Histogram
+	truncatedAttributes metric.Int64Counter
+	nPlusOne            metric.Int64Counter
+	droppedTagValues    metric.Int64Counter
+}
+
+func newInstruments(meter metric.Meter) (*instruments, error) {
+	var (
+		i   instruments
+		err error
+	)
+
+	if i.calls, err = meter.Int64Counter(QueryCountInstrument,
+		metric.WithDescription("Number of queries started"),
+	); err != nil {
+		return nil, err
+	}
+
+	if i.latency, err = meter.Float64Histogram(LatencyInstrument,
+		metric.WithDescription("The latency of calls in milliseconds"),
+		metric.WithUnit("ms"),
+		metric.WithExplicitBucketBoundaries(ocgorm.DefaultMillisecondsDistribution.Buckets...),
+	); err != nil {
+		return nil, err
+	}
+
+	if i.truncatedAttributes, err = meter.Int64Counter(TruncatedAttributesInstrument,
+		metric.WithDescription("Number of span attribute values truncated"),
+	); err != nil {
+		return nil, err
+	}
+
+	if i.nPlusOne, err = meter.Int64Counter(NPlusOneInstrument,
+		metric.WithDescription("Number of repeated queries detected within a request"),
+	); err != nil {
+		return nil, err
+	}
+
+	if i.droppedTagValues, err = meter.Int64Counter(DroppedTagValuesInstrument,
+		metric.WithDescription("Number of tag values replaced by the cardinality limiter"),
+	); err != nil {
+		return nil, err
+	}
+
+	return &i, nil
+}
+
+// RecordStats observes the connection pool statistics of the database each time metrics are collected.
+// Only the MeterProvider option is used. Call the returned function to stop observing the pool.
+func RecordStats(db *gorm.DB, name string, opts ...Option) (fnStop func() error, err error) {
+	meter := newConfig(opts...).MeterProvider.Meter(ScopeName)
+
+	openConnections, err1 := meter.Int64ObservableGauge(OpenConnectionsInstrument, metric.WithDescription("Count of open connections in the pool"))
+	idleConnections, err2 := meter.Int64ObservableGauge(IdleConnectionsInstrument, metric.WithDescription("Count of idle connections in the pool"))
+	activeConnections, err3 := meter.Int64ObservableGauge(ActiveConnectionsInstrument, metric.WithDescription("Count of active connections in the pool"))
+	waitCount, err4 := meter.Int64ObservableCounter(WaitCountInstrument, metric.WithDescription("The total number of connections waited for"))
+	waitDuration, err5 := meter.Float64ObservableCounter(WaitDurationInstrument, metric.WithDescription("The total time blocked waiting for a new connection"), metric.WithUnit("ms"))
+	idleClosed, err6 := meter.Int64ObservableCounter(IdleClosedInstrument, metric.WithDescription("The total number of connections closed due to SetMaxIdleConns"))
+	lifetimeClosed, err7 := meter.Int64ObservableCounter(LifetimeClosedInstrument, metric.WithDescription("The total number of connections closed due to SetConnMaxLifetime"))
+
+	if err := errors.Join(err1, err2, err3, err4, err5, err6, err7); err != nil {
+		return nil, err
+	}
+
+	attributes := metric.WithAttributes(attribute.String(ocgorm.DatabaseName.Name(), name))
+
+	registration, err := meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+
+		dbStats := sqlDB.Stats()
+
+		o.ObserveInt64(openConnections, int64(dbStats.OpenConnections), attributes)
+		o.ObserveInt64(idleConnections, int64(dbStats.Idle), attributes)
+		o.ObserveInt64(activeConnections, int64(dbStats.InUse), attributes)
+		o.ObserveInt64(waitCount, dbStats.WaitCount, attributes)
+		o.ObserveFloat64(waitDuration, float64(dbStats.WaitDuration.Nanoseconds())/1e6, attributes)
+		o.ObserveInt64(idleClosed, dbStats.MaxIdleClosed, attributes)
+		o.ObserveInt64(lifetimeClosed, dbStats.MaxLifetimeClosed, attributes)
+
+		return nil
+	}, openConnections, idleConnections, activeConnections, waitCount, waitDuration, idleClosed, lifetimeClosed)
+	if err != nil {
+		return nil, err
+	}
+
+	return registration.Unregister, nil
+}
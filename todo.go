@@ -0,0 +1,148 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Todo is the resource exposed by the example API.
+type Todo struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Title     string    `json:"title" binding:"required"`
+	Completed bool      `json:"completed"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type todoHandler struct {
+	db *gorm.DB
+}
+
+func registerTodos(router gin.IRouter, h *todoHandler) {
+	todos := router.Group("/todos")
+
+	todos.GET("", h.list)
+	todos.POST("", h.create)
+	todos.GET("/:id", h.get)
+	todos.PUT("/:id", h.update)
+	todos.DELETE("/:id", h.delete)
+}
+
+func (h *todoHandler) list(c *gin.Context) {
+	var todos []Todo
+
+	if err := h.db.WithContext(c.Request.Context()).Order("id").Find(&todos).Error; err != nil {
+		abortWithError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, todos)
+}
+
+func (h *todoHandler) create(c *gin.Context) {
+	var todo Todo
+
+	if err := c.ShouldBindJSON(&todo); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	todo.ID = 0
+
+	if err := h.db.WithContext(c.Request.Context()).Create(&todo).Error; err != nil {
+		abortWithError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusCreated, todo)
+}
+
+func (h *todoHandler) get(c *gin.Context) {
+	todo, ok := h.find(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, todo)
+}
+
+func (h *todoHandler) update(c *gin.Context) {
+	todo, ok := h.find(c)
+	if !ok {
+		return
+	}
+
+	var input Todo
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+
+		return
+	}
+
+	todo.Title = input.Title
+	todo.Completed = input.Completed
+
+	if err := h.db.WithContext(c.Request.Context()).Save(&todo).Error; err != nil {
+		abortWithError(c, err)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, todo)
+}
+
+func (h *todoHandler) delete(c *gin.Context) {
+	todo, ok := h.find(c)
+	if !ok {
+		return
+	}
+
+	if err := h.db.WithContext(c.Request.Context()).Delete(&todo).Error; err != nil {
+		abortWithError(c, err)
+
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// find loads the todo identified by the id path parameter, it aborts the request on failure.
+func (h *todoHandler) find(c *gin.Context) (Todo, bool) {
+	var todo Todo
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+
+		return todo, false
+	}
+
+	if err := h.db.WithContext(c.Request.Context()).First(&todo, id).Error; err != nil {
+		abortWithError(c, err)
+
+		return todo, false
+	}
+
+	return todo, true
+}
+
+func abortWithError(c *gin.Context, err error) {
+	_ = c.Error(err)
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "not found"})
+
+		return
+	}
+
+	c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+}